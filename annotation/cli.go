@@ -0,0 +1,57 @@
+package annotation
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	idictconfig "github.com/lai323/idict/config"
+	"github.com/lai323/idict/wordset"
+	"github.com/spf13/cobra"
+)
+
+type Options struct {
+	Tag     *string
+	Starred *bool
+}
+
+// Search 列出有注释的单词，参数为匹配单词或笔记的正则表达式
+func Search(config *idictconfig.Config, options Options) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if config.StoragePath == "" {
+			return errors.New("StoragePath empty")
+		}
+		var (
+			grep *regexp.Regexp
+			err  error
+		)
+		if len(args) == 1 {
+			grep, err = regexp.Compile("(?i)" + args[0])
+			if err != nil {
+				return fmt.Errorf("invalid regexp %s", err.Error())
+			}
+		}
+		annotations, err := wordset.NewAnnotations(config.StoragePath)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, word := range annotations.Search(grep, *options.Tag, *options.Starred) {
+			a := annotations.Get(word)
+			star := ""
+			if a.Star {
+				star = "*"
+			}
+			var tags []string
+			for _, t := range a.Tags {
+				tags = append(tags, "#"+t)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", word, star, strings.Join(tags, " "), a.Note)
+		}
+		return w.Flush()
+	}
+}
@@ -0,0 +1,166 @@
+package audio
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+type recordPlayer struct {
+	files []string
+}
+
+func (p *recordPlayer) Play(file string) error {
+	p.files = append(p.files, file)
+	return nil
+}
+
+func TestCommandPlayer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	out := path.Join(dir, "out")
+	p := CommandPlayer{Path: "sh", Args: []string{"-c", `echo "$0 $1" > ` + out, "{file}", "{speed}"}, Speed: 1.5}
+	err = p.Play("/tmp/a b.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadFile(out)
+	if strings.TrimSpace(string(b)) != "/tmp/a b.mp3 1.5" {
+		t.Errorf("played %q", b)
+	}
+
+	err = CommandPlayer{Path: "false"}.Play("x.mp3")
+	if err == nil {
+		t.Errorf("expected error from failing player")
+	}
+}
+
+func TestSpeakerCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	player := &recordPlayer{}
+	s := Speaker{Player: player, Cache: AudioCache{StorageDir: dir}, Voice: DefaultVoice}
+	file := s.Cache.File("Hello", DefaultVoice, ".mp3")
+	if !strings.HasPrefix(file, path.Join(s.Cache.CacheDir(), DefaultVoice)+"/") {
+		t.Fatalf("cache file %s", file)
+	}
+	err = os.MkdirAll(path.Dir(file), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(file, []byte("mp3"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 已缓存的音频不需要网络
+	err = s.Speak("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(player.files) != 1 || player.files[0] != file {
+		t.Errorf("played %v", player.files)
+	}
+
+	err = Speaker{}.Speak("hello")
+	if err != ErrNoPlayer {
+		t.Errorf("Speak without player: %v", err)
+	}
+}
+
+func TestVoiceName(t *testing.T) {
+	for _, c := range []struct {
+		accent, gender, voice string
+		err                   bool
+	}{
+		{"", "", "en_us_female", false},
+		{"uk", "male", "en_uk_male", false},
+		{"us", "male", "en_us_male", false},
+		{"au", "", "", true},
+		{"us", "robot", "", true},
+	} {
+		voice, err := VoiceName(c.accent, c.gender)
+		if voice != c.voice || (err != nil) != c.err {
+			t.Errorf("VoiceName(%q, %q) = %q, %v", c.accent, c.gender, voice, err)
+		}
+	}
+
+	s := Speaker{Voice: "en_us_male", Gender: "male"}
+	if s.AccentVoice("uk") != "en_uk_male" {
+		t.Errorf("AccentVoice uk = %s", s.AccentVoice("uk"))
+	}
+}
+
+func TestSpeakerTTSFallback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	player := &recordPlayer{}
+	s := Speaker{
+		Player:      player,
+		Synthesizer: CommandSynthesizer{Path: "sh", Args: []string{"-c", `echo "$0 $1" > "$2"`, "{text}", "{lang}", "{file}"}},
+		Sources:     []string{SourceTTS},
+		Cache:       AudioCache{StorageDir: dir},
+		Voice:       "en_uk_female",
+	}
+	err = s.Speak("take off")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(player.files) != 1 || path.Ext(player.files[0]) != ".wav" {
+		t.Fatalf("played %v", player.files)
+	}
+	b, _ := ioutil.ReadFile(player.files[0])
+	if strings.TrimSpace(string(b)) != "take off en-gb" {
+		t.Errorf("synthesized %q", b)
+	}
+
+	// 第二次直接使用缓存
+	s.Synthesizer = CommandSynthesizer{Path: "false"}
+	err = s.Speak("take off")
+	if err != nil || len(player.files) != 2 || player.files[1] != player.files[0] {
+		t.Errorf("cached tts: %v %v", player.files, err)
+	}
+
+	s.Synthesizer = nil
+	err = s.Speak("other")
+	if err == nil {
+		t.Errorf("expected error without tts engine")
+	}
+}
+
+func TestExpandArgs(t *testing.T) {
+	// 替换后的内容中的占位符不会再被替换
+	args := expandArgs([]string{"{text}", "-w", "{file}"}, map[string]string{"text": "{file} {speed}", "file": "a.wav", "speed": "1"})
+	if strings.Join(args, "|") != "{file} {speed}|-w|a.wav" {
+		t.Errorf("expanded %q", args)
+	}
+}
+
+func TestSpeakerInvalidVoice(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := Speaker{Player: &recordPlayer{}, Cache: AudioCache{StorageDir: dir}, Voice: DefaultVoice, Sources: []string{SourceTTS}}
+	for _, voice := range []string{"..", "../..", "a/b", `a\b`} {
+		if _, err := s.Fetch("hello", voice); err == nil || !strings.Contains(err.Error(), "invalid voice") {
+			t.Errorf("voice %q: %v", voice, err)
+		}
+	}
+}
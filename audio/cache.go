@@ -0,0 +1,76 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"time"
+
+	"github.com/lai323/idict/wordset"
+)
+
+type AudioCache struct {
+	StorageDir string
+}
+
+func (c AudioCache) CacheDir() string {
+	return fmt.Sprintf("%s/audiocache", c.StorageDir)
+}
+
+// File 返回单词在某个发音下的缓存文件，不同发音分目录存放
+func (c AudioCache) File(text, voice, ext string) string {
+	return path.Join(c.CacheDir(), voice, wordset.CacheName(text)+ext)
+}
+
+func (c AudioCache) Exist(file string) (bool, error) {
+	info, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	// 下载失败可能留下空文件
+	return info.Size() != 0, nil
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Download 下载音频到 file，先写入临时文件，完成后再重命名
+func Download(url, file string) error {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return fmt.Errorf("download audio %s", err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download audio %s", resp.Status)
+	}
+
+	err = os.MkdirAll(path.Dir(file), 0755)
+	if err != nil {
+		return fmt.Errorf("audio cache MkdirAll %s", err.Error())
+	}
+	tmp, err := ioutil.TempFile(path.Dir(file), ".tmp-")
+	if err != nil {
+		return fmt.Errorf("audio cache TempFile %s", err.Error())
+	}
+	n, err := io.Copy(tmp, resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n == 0 {
+		err = fmt.Errorf("empty response")
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("download audio %s", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package audio
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	idictconfig "github.com/lai323/idict/config"
+)
+
+type Player interface {
+	Play(file string) error
+}
+
+// 内置的播放器命令模板，{file} 会被替换为音频文件路径，{speed} 替换为播放速度
+var Players = map[string][]string{
+	"ffplay": {"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet", "-af", "atempo={speed}", "{file}"},
+	"mpv":    {"mpv", "--no-video", "--really-quiet", "--speed={speed}", "{file}"},
+	"aplay":  {"aplay", "-q", "{file}"},
+	"paplay": {"paplay", "{file}"},
+}
+
+// CommandPlayer 运行外部命令播放音频，Args 中没有 {file} 时文件路径追加在最后
+type CommandPlayer struct {
+	Path  string
+	Args  []string
+	Speed float64
+}
+
+func (p CommandPlayer) Play(file string) error {
+	speed := p.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	args := expandArgs(p.Args, map[string]string{
+		"file":  file,
+		"speed": strconv.FormatFloat(speed, 'f', -1, 64),
+	})
+	if !containsPlaceholder(p.Args, "file") {
+		args = append(args, file)
+	}
+	out, err := exec.Command(p.Path, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("play %s: %s %s", file, err.Error(), strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// NewPlayer 根据配置选择播放器，没有配置任何播放器时返回 nil
+// 优先使用 AudioCommand，其次 AudioPlayer，最后兼容旧的 FfplayPath 配置
+func NewPlayer(config idictconfig.Config) (Player, error) {
+	if len(config.AudioCommand) != 0 {
+		return CommandPlayer{Path: config.AudioCommand[0], Args: config.AudioCommand[1:], Speed: config.VoiceSpeed}, nil
+	}
+	if config.AudioPlayer != "" {
+		tmpl, ok := Players[config.AudioPlayer]
+		if !ok {
+			return nil, fmt.Errorf("unknown AudioPlayer %s", config.AudioPlayer)
+		}
+		return CommandPlayer{Path: tmpl[0], Args: tmpl[1:], Speed: config.VoiceSpeed}, nil
+	}
+	// 旧的配置只有在 FfplayArgs 中使用 {speed} 时才支持播放速度
+	if config.FfplayPath != "" {
+		return CommandPlayer{Path: config.FfplayPath, Args: config.FfplayArgs, Speed: config.VoiceSpeed}, nil
+	}
+	return nil, nil
+}
+
+// expandArgs 一次替换所有占位符，替换后的内容中的占位符不会再被替换
+func expandArgs(args []string, values map[string]string) []string {
+	var oldnew []string
+	for k, v := range values {
+		oldnew = append(oldnew, "{"+k+"}", v)
+	}
+	r := strings.NewReplacer(oldnew...)
+	expanded := make([]string, 0, len(args))
+	for _, arg := range args {
+		expanded = append(expanded, r.Replace(arg))
+	}
+	return expanded
+}
+
+func containsPlaceholder(args []string, name string) bool {
+	for _, arg := range args {
+		if strings.Contains(arg, "{"+name+"}") {
+			return true
+		}
+	}
+	return false
+}
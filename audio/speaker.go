@@ -0,0 +1,173 @@
+package audio
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	idictconfig "github.com/lai323/idict/config"
+)
+
+const DefaultVoice = "en_us_female"
+
+var ErrNoPlayer = errors.New("no audio player configured")
+
+// VoiceName 返回 frdic 的发音名称，accent 为 us 或 uk，gender 为 female 或 male
+func VoiceName(accent, gender string) (string, error) {
+	if accent == "" {
+		accent = "us"
+	}
+	if gender == "" {
+		gender = "female"
+	}
+	if accent != "us" && accent != "uk" {
+		return "", fmt.Errorf("unknown VoiceAccent %s", accent)
+	}
+	if gender != "female" && gender != "male" {
+		return "", fmt.Errorf("unknown VoiceGender %s", gender)
+	}
+	return "en_" + accent + "_" + gender, nil
+}
+
+// ValidVoice 判断发音名称是否可以作为缓存目录名
+func ValidVoice(voice string) bool {
+	return voice != "" && voice != "." && voice != ".." && !strings.ContainsAny(voice, "/\\\x00")
+}
+
+func FrdicURL(text, voice string) string {
+	txt := "QYN" + base64.StdEncoding.EncodeToString([]byte(text))
+	return "https://api.frdic.com/api/v2/speech/speakweb?langid=en&voicename=" + url.QueryEscape(voice) + "&txt=" + url.QueryEscape(txt)
+}
+
+// Speaker 第一次播放时把音频下载或合成到缓存，之后直接从磁盘播放
+type Speaker struct {
+	Player Player
+	// 本地语音合成，没有配置时为 nil
+	Synthesizer Synthesizer
+	// 获取音频的顺序，前一个失败时使用下一个
+	Sources []string
+	Cache   AudioCache
+	// 默认发音
+	Voice string
+	// 配置的发音性别，用于选择美式或英式发音
+	Gender string
+}
+
+func NewSpeaker(config idictconfig.Config) (Speaker, error) {
+	player, err := NewPlayer(config)
+	if err != nil {
+		return Speaker{}, err
+	}
+	voice, err := VoiceName(config.VoiceAccent, config.VoiceGender)
+	if err != nil {
+		return Speaker{}, err
+	}
+	sources := config.VoiceSources
+	if len(sources) == 0 {
+		sources = DefaultVoiceSources
+	}
+	for _, source := range sources {
+		if source != SourceFrdic && source != SourceTTS {
+			return Speaker{}, fmt.Errorf("unknown VoiceSources %s", source)
+		}
+	}
+	return Speaker{
+		Player:      player,
+		Synthesizer: NewSynthesizer(config),
+		Sources:     sources,
+		Cache:       AudioCache{StorageDir: config.StoragePath},
+		Voice:       voice,
+		Gender:      config.VoiceGender,
+	}, nil
+}
+
+// AccentVoice 返回配置的性别下 accent 口音的发音名称
+func (s Speaker) AccentVoice(accent string) string {
+	voice, err := VoiceName(accent, s.Gender)
+	if err != nil {
+		return s.Voice
+	}
+	return voice
+}
+
+func (s Speaker) Enabled() bool {
+	return s.Player != nil
+}
+
+// Fetch 返回 text 在 voice 发音下的音频缓存文件，voice 为空时使用默认发音
+// 按 Sources 的顺序先查找已有的缓存，都没有时依次尝试下载或本地合成
+func (s Speaker) Fetch(text, voice string) (string, error) {
+	if voice == "" {
+		voice = s.Voice
+	}
+	// 发音名称来自缓存或外部词典，会作为缓存目录名
+	if !ValidVoice(voice) {
+		return "", fmt.Errorf("invalid voice %q", voice)
+	}
+	sources := s.Sources
+	if len(sources) == 0 {
+		sources = []string{SourceFrdic}
+	}
+
+	for _, source := range sources {
+		file := s.sourceFile(source, text, voice)
+		exist, err := s.Cache.Exist(file)
+		if err != nil {
+			return file, err
+		}
+		if exist {
+			return file, nil
+		}
+	}
+
+	var errs []string
+	for _, source := range sources {
+		file := s.sourceFile(source, text, voice)
+		var err error
+		switch source {
+		case SourceFrdic:
+			err = Download(FrdicURL(text, voice), file)
+		case SourceTTS:
+			if s.Synthesizer == nil {
+				err = errors.New("no tts engine configured")
+			} else {
+				err = s.Synthesizer.Synthesize(text, voice, file)
+			}
+		default:
+			err = fmt.Errorf("unknown voice source %s", source)
+		}
+		if err == nil {
+			return file, nil
+		}
+		errs = append(errs, source+": "+err.Error())
+	}
+	return "", errors.New(strings.Join(errs, "; "))
+}
+
+func (s Speaker) sourceFile(source, text, voice string) string {
+	if source == SourceTTS {
+		return s.Cache.File(text, path.Join(SourceTTS, voice), ".wav")
+	}
+	return s.Cache.File(text, voice, ".mp3")
+}
+
+func (s Speaker) Speak(text string) error {
+	return s.SpeakVoice(text, s.Voice)
+}
+
+func (s Speaker) SpeakVoice(text, voice string) error {
+	if !s.Enabled() {
+		return ErrNoPlayer
+	}
+	if text == "" {
+		return nil
+	}
+	file, err := s.Fetch(text, voice)
+	if err != nil {
+		return err
+	}
+	return s.Player.Play(file)
+}
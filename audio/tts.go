@@ -0,0 +1,79 @@
+package audio
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	idictconfig "github.com/lai323/idict/config"
+)
+
+const (
+	SourceFrdic = "frdic"
+	SourceTTS   = "tts"
+)
+
+var DefaultVoiceSources = []string{SourceFrdic, SourceTTS}
+
+// 默认的本地语音合成命令，{text} 为要合成的文本，{file} 为输出的 wav 文件，
+// {lang} 为 en-us 或 en-gb，{variant} 为 espeak 的声音变体
+var DefaultTTSCommand = []string{"espeak-ng", "-v", "{lang}+{variant}", "-w", "{file}", "{text}"}
+
+type Synthesizer interface {
+	Synthesize(text, voice, file string) error
+}
+
+// CommandSynthesizer 运行外部命令把文本合成为 wav 文件
+type CommandSynthesizer struct {
+	Path string
+	Args []string
+}
+
+func (s CommandSynthesizer) Synthesize(text, voice, file string) error {
+	lang, variant := "en-us", "f3"
+	parts := strings.Split(voice, "_")
+	if len(parts) == 3 {
+		if parts[1] == "uk" {
+			lang = "en-gb"
+		}
+		if parts[2] == "male" {
+			variant = "m3"
+		}
+	}
+
+	err := os.MkdirAll(path.Dir(file), 0755)
+	if err != nil {
+		return fmt.Errorf("tts MkdirAll %s", err.Error())
+	}
+	args := expandArgs(s.Args, map[string]string{
+		"text":    text,
+		"file":    file,
+		"lang":    lang,
+		"variant": variant,
+	})
+	out, err := exec.Command(s.Path, args...).CombinedOutput()
+	if err != nil {
+		os.Remove(file)
+		return fmt.Errorf("tts %s: %s %s", s.Path, err.Error(), strings.TrimSpace(string(out)))
+	}
+	info, err := os.Stat(file)
+	if err != nil || info.Size() == 0 {
+		os.Remove(file)
+		return fmt.Errorf("tts %s produced no audio", s.Path)
+	}
+	return nil
+}
+
+// NewSynthesizer 返回配置的语音合成命令，没有配置时如果安装了 espeak-ng 则使用它，否则返回 nil
+func NewSynthesizer(config idictconfig.Config) Synthesizer {
+	tmpl := config.TTSCommand
+	if len(tmpl) == 0 {
+		if _, err := exec.LookPath(DefaultTTSCommand[0]); err != nil {
+			return nil
+		}
+		tmpl = DefaultTTSCommand
+	}
+	return CommandSynthesizer{Path: tmpl[0], Args: tmpl[1:]}
+}
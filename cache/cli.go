@@ -0,0 +1,235 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	idictconfig "github.com/lai323/idict/config"
+	"github.com/lai323/idict/dict"
+	"github.com/lai323/idict/wordset"
+	"github.com/spf13/cobra"
+)
+
+func newClient(config *idictconfig.Config) (dict.EuDictClient, error) {
+	if config.StoragePath == "" {
+		return dict.EuDictClient{}, errors.New("StoragePath empty")
+	}
+	return dict.NewEuDictClient(*config)
+}
+
+func Stats(config *idictconfig.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		cli, err := newClient(config)
+		if err != nil {
+			return err
+		}
+		wordcache := cli.WordCache()
+
+		var (
+			total, expired, empty int
+			size                  int64
+			providers             = map[string]int{}
+			versions              = map[int]int{}
+		)
+		err = wordcache.Walk(func(file string, entry wordset.CacheEntry) error {
+			info, err := os.Stat(file)
+			if err != nil {
+				return err
+			}
+			total++
+			size += info.Size()
+			if wordcache.Expired(entry) {
+				expired++
+			}
+			if len(entry.Word.Translates) == 0 {
+				empty++
+			}
+			provider := entry.Meta.Provider
+			if provider == "" {
+				provider = "unknown"
+			}
+			providers[provider]++
+			versions[entry.Meta.ParserVersion]++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%-10s %s\n", "dir", wordcache.CacheDir())
+		fmt.Printf("%-10s %d\n", "entries", total)
+		fmt.Printf("%-10s %.1f KB\n", "size", float64(size)/1024)
+		fmt.Printf("%-10s %d\n", "expired", expired)
+		fmt.Printf("%-10s %d\n", "empty", empty)
+		for _, p := range sortedKeys(providers) {
+			fmt.Printf("%-10s %s %d\n", "provider", p, providers[p])
+		}
+		var vs []int
+		for v := range versions {
+			vs = append(vs, v)
+		}
+		sort.Ints(vs)
+		for _, v := range vs {
+			fmt.Printf("%-10s v%d %d\n", "parser", v, versions[v])
+		}
+		return nil
+	}
+}
+
+func Prune(config *idictconfig.Config, all *bool) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		cli, err := newClient(config)
+		if err != nil {
+			return err
+		}
+		wordcache := cli.WordCache()
+
+		removed := 0
+		err = wordcache.Walk(func(file string, entry wordset.CacheEntry) error {
+			if !*all && !wordcache.Expired(entry) {
+				return nil
+			}
+			removed++
+			return os.Remove(file)
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("removed %d entries\n", removed)
+		return nil
+	}
+}
+
+func Refresh(config *idictconfig.Config, all *bool) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		cli, err := newClient(config)
+		if err != nil {
+			return err
+		}
+		wordcache := cli.WordCache()
+
+		words := args
+		if len(words) == 0 {
+			err = wordcache.Walk(func(file string, entry wordset.CacheEntry) error {
+				if *all || wordcache.Expired(entry) {
+					words = append(words, entry.Word.Text)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		failed := 0
+		for i, text := range words {
+			err, word := cli.Refresh(text)
+			status := "ok"
+			if err != nil {
+				failed++
+				status = err.Error()
+			} else if len(word.Translates) == 0 {
+				status = "no translation"
+			}
+			fmt.Printf("[%d/%d] %s: %s\n", i+1, len(words), text, status)
+		}
+		if failed != 0 {
+			return fmt.Errorf("%d of %d words failed to refresh", failed, len(words))
+		}
+		return nil
+	}
+}
+
+// Export 把所有缓存以 JSON 数组写入文件，没有指定文件时写到标准输出
+func Export(config *idictconfig.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) > 1 {
+			return errors.New("Only one export file can be specified")
+		}
+		cli, err := newClient(config)
+		if err != nil {
+			return err
+		}
+
+		entries := []wordset.CacheEntry{}
+		err = cli.WordCache().Walk(func(file string, entry wordset.CacheEntry) error {
+			entries = append(entries, entry)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+		if len(args) == 1 {
+			f, err := os.Create(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+}
+
+// Import 导入 Export 生成的文件，已存在的缓存只有在导入的版本更新时才会被覆盖
+func Import(config *idictconfig.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Import file required")
+		}
+		cli, err := newClient(config)
+		if err != nil {
+			return err
+		}
+		wordcache := cli.WordCache()
+
+		filebyte, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("read file %s %s", args[0], err.Error())
+		}
+		var entries []wordset.CacheEntry
+		err = json.Unmarshal(filebyte, &entries)
+		if err != nil {
+			return fmt.Errorf("cache import json Unmarshal %s %s", args[0], err.Error())
+		}
+
+		imported := 0
+		for _, entry := range entries {
+			if entry.Word.Text == "" {
+				continue
+			}
+			old, exist, err := wordcache.Entry(entry.Word.Text)
+			if err != nil {
+				return err
+			}
+			if exist && old.Meta.FetchedAt >= entry.Meta.FetchedAt {
+				continue
+			}
+			err = wordcache.SetEntry(entry)
+			if err != nil {
+				return err
+			}
+			imported++
+		}
+		fmt.Printf("imported %d of %d entries\n", imported, len(entries))
+		return nil
+	}
+}
+
+func sortedKeys(m map[string]int) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,244 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	idictconfig "github.com/lai323/idict/config"
+	"github.com/lai323/idict/wordset"
+)
+
+// 外部命令代替在线词典，refresh 不需要网络
+const providerScript = `#!/bin/sh
+case "$1" in
+stale|fresh)
+	echo '{"translates": [{"mean": "new"}]}'
+	;;
+*)
+	echo "not found: $1" >&2
+	exit 1
+	;;
+esac
+`
+
+func newTestCache(t *testing.T, dir string) (*idictconfig.Config, wordset.WordCache) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "provider.sh")
+	err = ioutil.WriteFile(script, []byte(providerScript), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &idictconfig.Config{
+		StoragePath: dir,
+		CacheTTL:    1,
+		Provider:    idictconfig.Provider{Name: "test", Command: []string{script}},
+	}
+	wordcache, err := wordset.NewProviderWordCache(dir, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wordcache.TTL = time.Hour
+	return config, wordcache
+}
+
+// seed 写入一个未过期和两个过期的缓存
+func seed(t *testing.T, wordcache wordset.WordCache) {
+	now := time.Now().Unix()
+	for _, entry := range []wordset.CacheEntry{
+		{Meta: wordset.CacheMeta{FetchedAt: now, Provider: "test"}, Word: wordset.Word{Text: "fresh", Translates: []wordset.Translate{{Mean: "old"}}}},
+		{Meta: wordset.CacheMeta{FetchedAt: now - 7200, Provider: "test"}, Word: wordset.Word{Text: "stale", Translates: []wordset.Translate{{Mean: "old"}}}},
+		{Meta: wordset.CacheMeta{FetchedAt: now, Provider: "test"}, Word: wordset.Word{Text: "empty"}},
+	} {
+		err := wordcache.SetEntry(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// capture 返回 fn 写到标准输出的内容
+func capture(t *testing.T, fn func() error) (string, error) {
+	f, err := ioutil.TempFile("", "idict-stdout-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	stdout := os.Stdout
+	os.Stdout = f
+	err = fn()
+	os.Stdout = stdout
+	out, readErr := ioutil.ReadFile(f.Name())
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	return string(out), err
+}
+
+func cached(t *testing.T, wordcache wordset.WordCache, text string) (wordset.CacheEntry, bool) {
+	entry, exist, err := wordcache.Entry(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entry, exist
+}
+
+func TestStats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config, wordcache := newTestCache(t, dir)
+	seed(t, wordcache)
+
+	out, err := capture(t, func() error { return Stats(config)(nil, nil) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"entries    3\n", "expired    2\n", "empty      1\n", "provider   test 3\n", "parser     v0 3\n"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("missing %q in\n%s", line, out)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config, wordcache := newTestCache(t, dir)
+	seed(t, wordcache)
+	// 缓存目录和分片目录中不是缓存的文件
+	others := []string{
+		filepath.Join(wordcache.CacheDir(), "notes.txt"),
+		filepath.Join(filepath.Dir(filepath.Join(wordcache.CacheDir(), wordset.CacheKey("fresh"))), "notes.txt"),
+	}
+	for _, file := range others {
+		err = ioutil.WriteFile(file, []byte("keep me"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all := false
+	out, err := capture(t, func() error { return Prune(config, &all)(nil, nil) })
+	if err != nil || out != "removed 2 entries\n" {
+		t.Fatalf("prune %q %v", out, err)
+	}
+	if _, exist := cached(t, wordcache, "fresh"); !exist {
+		t.Errorf("unexpired entry removed")
+	}
+	for _, text := range []string{"stale", "empty"} {
+		if _, exist := cached(t, wordcache, text); exist {
+			t.Errorf("expired entry %s kept", text)
+		}
+	}
+
+	all = true
+	out, err = capture(t, func() error { return Prune(config, &all)(nil, nil) })
+	if err != nil || out != "removed 1 entries\n" {
+		t.Fatalf("prune all %q %v", out, err)
+	}
+	if _, exist := cached(t, wordcache, "fresh"); exist {
+		t.Errorf("entry kept with --all")
+	}
+	for _, file := range others {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("non-cache file removed %v", err)
+		}
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config, wordcache := newTestCache(t, dir)
+	seed(t, wordcache)
+	fresh, _ := cached(t, wordcache, "fresh")
+
+	// 只刷新过期的缓存，empty 在外部命令中查不到，按缓存文件的顺序刷新
+	all := false
+	out, err := capture(t, func() error { return Refresh(config, &all)(nil, nil) })
+	if err == nil || err.Error() != "1 of 2 words failed to refresh" {
+		t.Errorf("refresh %v", err)
+	}
+	if !strings.Contains(out, "] empty: Provider test not found: empty\n") || !strings.Contains(out, "] stale: ok\n") {
+		t.Errorf("refresh output\n%s", out)
+	}
+	stale, _ := cached(t, wordcache, "stale")
+	if stale.Word.Translates[0].Mean != "new" || wordcache.Expired(stale) {
+		t.Errorf("stale %+v", stale)
+	}
+	if entry, _ := cached(t, wordcache, "fresh"); entry.Word.Translates[0].Mean != "old" || entry.Meta != fresh.Meta {
+		t.Errorf("unexpired entry refreshed %+v", entry)
+	}
+
+	// 指定的单词总是刷新
+	out, err = capture(t, func() error { return Refresh(config, &all)(nil, []string{"fresh"}) })
+	if err != nil || out != "[1/1] fresh: ok\n" {
+		t.Errorf("refresh fresh %q %v", out, err)
+	}
+	if entry, _ := cached(t, wordcache, "fresh"); entry.Word.Translates[0].Mean != "new" {
+		t.Errorf("fresh %+v", entry)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src, srcCache := newTestCache(t, filepath.Join(dir, "src"))
+	dst, dstCache := newTestCache(t, filepath.Join(dir, "dst"))
+	seed(t, srcCache)
+
+	file := filepath.Join(dir, "cache.json")
+	_, err = capture(t, func() error { return Export(src)(nil, []string{file}) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 目标中较新的缓存不会被覆盖
+	newer := wordset.CacheEntry{
+		Meta: wordset.CacheMeta{FetchedAt: time.Now().Unix() + 60, Provider: "test"},
+		Word: wordset.Word{Text: "fresh", Translates: []wordset.Translate{{Mean: "newer"}}},
+	}
+	err = dstCache.SetEntry(newer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := capture(t, func() error { return Import(dst)(nil, []string{file}) })
+	if err != nil || out != "imported 2 of 3 entries\n" {
+		t.Fatalf("import %q %v", out, err)
+	}
+	for _, text := range []string{"stale", "empty"} {
+		want, _ := cached(t, srcCache, text)
+		got, exist := cached(t, dstCache, text)
+		if !exist || got.Meta != want.Meta || got.Word.Text != want.Word.Text || len(got.Word.Translates) != len(want.Word.Translates) {
+			t.Errorf("%s imported %+v, want %+v", text, got, want)
+		}
+	}
+	if entry, _ := cached(t, dstCache, "fresh"); entry.Word.Translates[0].Mean != "newer" {
+		t.Errorf("newer entry overwritten %+v", entry)
+	}
+
+	// 再次导入时没有更新的缓存
+	out, err = capture(t, func() error { return Import(dst)(nil, []string{file}) })
+	if err != nil || out != "imported 0 of 3 entries\n" {
+		t.Errorf("import again %q %v", out, err)
+	}
+}
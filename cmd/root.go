@@ -5,27 +5,66 @@ import (
 	"log"
 	"os"
 
+	"github.com/lai323/idict/annotation"
+	"github.com/lai323/idict/cache"
 	idictconfig "github.com/lai323/idict/config"
+	"github.com/lai323/idict/daemon"
 	"github.com/lai323/idict/dict"
+	"github.com/lai323/idict/exporter"
+	"github.com/lai323/idict/extractor"
+	"github.com/lai323/idict/history"
+	"github.com/lai323/idict/importer"
+	"github.com/lai323/idict/lsp"
 	"github.com/lai323/idict/practice"
+	"github.com/lai323/idict/server"
+	"github.com/lai323/idict/userdict"
 	"github.com/lai323/idict/wordset"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 )
 
 var (
-	configPath    string
-	storagePath   string
-	wordSetImport string
-	wordSetList   bool
-	wordSetShow   string
-	wordDel       string
+	configPath            string
+	storagePath           string
+	wordImportName        string
+	wordImportFormat      string
+	wordImportHeader      bool
+	wordImportWordCol     string
+	wordImportTransCol    string
+	wordImportSentenceCol string
+	wordImportNoteCol     string
+	wordImportLang        string
+	wordExportFormat      string
+	wordExportAudio       bool
+	wordExportFetch       bool
+	wordExtractName       string
+	wordExtractFormat     string
+	wordExtractKnown      []string
+	wordExtractLimit      int
+	wordExtractMinCount   int
+	wordExtractMinLength  int
+	wordExtractDryRun     bool
+	wordInstallName       string
+	wordDedupeRemove      bool
+	wordDedupeLemma       bool
+	wordShowSort          string
+	wordShowState         string
+	wordDeleteForce       bool
+	cachePruneAll         bool
+	cacheRefreshAll       bool
+	historySince          string
+	historyGrep           string
+	historyCount          bool
+	noteTag               string
+	noteStarred           bool
+	serveAddr             string
+	lspWordSet            string
 
 	config   idictconfig.Config
 	rootCmd  = &cobra.Command{Use: "idict"}
 	transCmd = &cobra.Command{
 		Use:   "trans",
-		Short: "translate one word or sentence",
+		Short: "translate one word or sentence, Chinese input lists English words",
 		RunE: dict.Run(
 			&config,
 			afero.NewOsFs(),
@@ -46,14 +85,234 @@ var (
 	wordCmd = &cobra.Command{
 		Use:   "word",
 		Short: "manage word set",
-		RunE: wordset.Start(
-			&config,
-			afero.NewOsFs(),
-			&wordSetImport,
-			&wordSetList,
-			&wordSetShow,
-			&wordDel,
-		),
+	}
+	wordListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "list word sets and available builtin word sets",
+		Args:  cobra.NoArgs,
+		RunE:  wordset.List(&config),
+	}
+	wordShowCmd = &cobra.Command{
+		Use:   "show <set>",
+		Short: "show words of word set with practice state",
+		Args:  cobra.ExactArgs(1),
+		RunE:  wordset.Show(&config, &wordShowSort, &wordShowState),
+	}
+	wordImportCmd = &cobra.Command{
+		Use:   "import <file>...",
+		Short: "import word list, csv, tsv, anki or kindle vocabulary files",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: importer.Import(&config, afero.NewOsFs(), importer.Options{
+			Name:        &wordImportName,
+			Format:      &wordImportFormat,
+			Header:      &wordImportHeader,
+			WordCol:     &wordImportWordCol,
+			TransCol:    &wordImportTransCol,
+			SentenceCol: &wordImportSentenceCol,
+			NoteCol:     &wordImportNoteCol,
+			Lang:        &wordImportLang,
+		}),
+	}
+	wordExportCmd = &cobra.Command{
+		Use:   "export <set> [file]",
+		Short: "export word set as word list, anki deck, csv, markdown or json",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: exporter.Export(&config, exporter.Options{
+			Format: &wordExportFormat,
+			Audio:  &wordExportAudio,
+			Fetch:  &wordExportFetch,
+		}),
+	}
+	wordExtractCmd = &cobra.Command{
+		Use:   "extract <file>",
+		Short: "create word set from unknown words of text, markdown, html or srt file",
+		Args:  cobra.ExactArgs(1),
+		RunE: extractor.Extract(&config, extractor.Options{
+			Name:      &wordExtractName,
+			Format:    &wordExtractFormat,
+			Known:     &wordExtractKnown,
+			Limit:     &wordExtractLimit,
+			MinCount:  &wordExtractMinCount,
+			MinLength: &wordExtractMinLength,
+			DryRun:    &wordExtractDryRun,
+		}),
+	}
+	wordInstallCmd = &cobra.Command{
+		Use:   "install <builtin>...",
+		Short: "install or update builtin word sets, see word list for available ones",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  wordset.Install(&config, &wordInstallName),
+	}
+	wordAddCmd = &cobra.Command{
+		Use:   "add <set> <word>...",
+		Short: "add words to word set",
+		Args:  cobra.MinimumNArgs(2),
+		RunE:  wordset.Add(&config),
+	}
+	wordRmCmd = &cobra.Command{
+		Use:   "rm <set> <word>...",
+		Short: "remove words from word set",
+		Args:  cobra.MinimumNArgs(2),
+		RunE:  wordset.Remove(&config),
+	}
+	wordDeleteCmd = &cobra.Command{
+		Use:     "delete <set>",
+		Aliases: []string{"del"},
+		Short:   "delete word set",
+		Args:    cobra.ExactArgs(1),
+		RunE:    wordset.Delete(&config, &wordDeleteForce),
+	}
+	wordRenameCmd = &cobra.Command{
+		Use:   "rename <set> <new>",
+		Short: "rename word set",
+		Args:  cobra.ExactArgs(2),
+		RunE:  wordset.Rename(&config),
+	}
+	wordMergeCmd = &cobra.Command{
+		Use:   "merge <dst> <set>...",
+		Short: "merge word sets into dst",
+		Args:  cobra.MinimumNArgs(2),
+		RunE:  wordset.Merge(&config),
+	}
+	wordIntersectCmd = &cobra.Command{
+		Use:   "intersect <new> <set> <set>...",
+		Short: "create word set from words in all of the word sets",
+		Args:  cobra.MinimumNArgs(3),
+		RunE:  wordset.Intersect(&config),
+	}
+	wordSubtractCmd = &cobra.Command{
+		Use:   "subtract <new> <set> <set>...",
+		Short: "create word set from words in the first word set but not in the others",
+		Args:  cobra.MinimumNArgs(3),
+		RunE:  wordset.Subtract(&config),
+	}
+	wordDedupeCmd = &cobra.Command{
+		Use:   "dedupe [set...]",
+		Short: "show words in more than one word set",
+		RunE:  wordset.Dedupe(&config, &wordDedupeRemove, &wordDedupeLemma),
+	}
+	wordDiffCmd = &cobra.Command{
+		Use:   "diff <set> <set>",
+		Short: "show words only in one of two word sets",
+		Args:  cobra.ExactArgs(2),
+		RunE:  wordset.Diff(&config),
+	}
+	wordCopyCmd = &cobra.Command{
+		Use:   "copy <set> <new>",
+		Short: "copy word set",
+		Args:  cobra.ExactArgs(2),
+		RunE:  wordset.Copy(&config),
+	}
+	wordPrefetchCmd = &cobra.Command{
+		Use:   "prefetch <set>",
+		Short: "fetch all words of word set into cache for offline practice",
+		Args:  cobra.ExactArgs(1),
+		RunE:  wordset.Prefetch(&config, dict.StartPrefetch(&config)),
+	}
+
+	cacheCmd = &cobra.Command{
+		Use:   "cache",
+		Short: "manage word cache",
+	}
+	cacheStatsCmd = &cobra.Command{
+		Use:   "stats",
+		Short: "show word cache statistics",
+		RunE:  cache.Stats(&config),
+	}
+	cachePruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "remove expired cache entries",
+		RunE:  cache.Prune(&config, &cachePruneAll),
+	}
+	cacheRefreshCmd = &cobra.Command{
+		Use:   "refresh [word...]",
+		Short: "fetch expired cache entries or the given words again",
+		RunE:  cache.Refresh(&config, &cacheRefreshAll),
+	}
+	cacheExportCmd = &cobra.Command{
+		Use:   "export [file]",
+		Short: "export word cache as json",
+		RunE:  cache.Export(&config),
+	}
+	cacheImportCmd = &cobra.Command{
+		Use:   "import <file>",
+		Short: "import word cache exported by cache export",
+		RunE:  cache.Import(&config),
+	}
+	historyCmd = &cobra.Command{
+		Use:   "history",
+		Short: "list looked up words",
+		Args:  cobra.NoArgs,
+		RunE: history.Show(&config, history.Options{
+			Since: &historySince,
+			Grep:  &historyGrep,
+			Count: &historyCount,
+		}),
+	}
+	userdictCmd = &cobra.Command{
+		Use:   "userdict",
+		Short: "manage your own dictionary entries",
+	}
+	userdictEditCmd = &cobra.Command{
+		Use:   "edit",
+		Short: "edit your own dictionary with $EDITOR",
+		Args:  cobra.NoArgs,
+		RunE:  userdict.Edit(&config),
+	}
+	userdictImportCmd = &cobra.Command{
+		Use:   "import <file>...",
+		Short: "import dictionary entries from yaml files, existing entries are replaced",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  userdict.Import(&config),
+	}
+	userdictListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "list words of your own dictionary",
+		Args:  cobra.NoArgs,
+		RunE:  userdict.List(&config),
+	}
+	serveCmd = &cobra.Command{
+		Use:   "serve",
+		Short: "serve lookup, suggestion, word set and review JSON API on localhost",
+		Args:  cobra.NoArgs,
+		RunE: server.Serve(&config, server.Options{
+			Addr: &serveAddr,
+		}),
+	}
+	lspCmd = &cobra.Command{
+		Use:   "lsp",
+		Short: "language server over stdio: hover translations, completion from word sets, add to word set",
+		Args:  cobra.NoArgs,
+		RunE: lsp.Run(&config, lsp.Options{
+			WordSet: &lspWordSet,
+		}),
+	}
+	daemonCmd = &cobra.Command{
+		Use:   "daemon",
+		Short: "run in foreground and serve trans and prac over a unix socket for instant lookups",
+		Args:  cobra.NoArgs,
+		RunE:  daemon.Run(&config),
+	}
+	daemonStopCmd = &cobra.Command{
+		Use:   "stop",
+		Short: "stop the running daemon",
+		Args:  cobra.NoArgs,
+		RunE:  daemon.Stop(&config),
+	}
+	daemonStatusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "show whether the daemon is running",
+		Args:  cobra.NoArgs,
+		RunE:  daemon.Status(&config),
+	}
+	noteCmd = &cobra.Command{
+		Use:   "note [regexp]",
+		Short: "search starred, tagged and noted words, edit them with *, t and n in trans",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: annotation.Search(&config, annotation.Options{
+			Tag:     &noteTag,
+			Starred: &noteStarred,
+		}),
 	}
 )
 
@@ -69,14 +328,82 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", fmt.Sprintf("config file (default is %s)", idictconfig.DefaultConfigPath))
 	rootCmd.PersistentFlags().StringVar(&storagePath, "storage", "", fmt.Sprintf("storage dir (default is %s)", idictconfig.DefaultStorageDir))
 
-	wordCmd.PersistentFlags().StringVar(&wordSetImport, "import", "", "import word set file path")
-	wordCmd.PersistentFlags().BoolVar(&wordSetList, "list", false, "list all word set")
-	wordCmd.PersistentFlags().StringVar(&wordSetShow, "show", "", "show word set info")
-	wordCmd.PersistentFlags().StringVar(&wordDel, "del", "", "delete word set")
+	wordImportCmd.Flags().StringVar(&wordImportName, "name", "", "word set name (default is the file name)")
+	wordImportCmd.Flags().StringVar(&wordImportFormat, "format", "", "file format: plain, csv, tsv, anki, apkg or kindle (default is detected from the file)")
+	wordImportCmd.Flags().BoolVar(&wordImportHeader, "header", false, "first row of csv/tsv is header")
+	wordImportCmd.Flags().StringVar(&wordImportWordCol, "word-col", "1", "column of word, number from 1 or header name")
+	wordImportCmd.Flags().StringVar(&wordImportTransCol, "trans-col", "", "column of custom translation (default is 2 for anki)")
+	wordImportCmd.Flags().StringVar(&wordImportSentenceCol, "sentence-col", "", "column of example sentence")
+	wordImportCmd.Flags().StringVar(&wordImportNoteCol, "note-col", "", "column of note")
+	wordImportCmd.Flags().StringVar(&wordImportLang, "lang", "en", "only import words of this language from kindle vocab.db, empty for all")
+	wordExportCmd.Flags().StringVar(&wordExportFormat, "format", "plain", "export format: plain, anki, csv, md or json")
+	wordExportCmd.Flags().BoolVar(&wordExportAudio, "audio", false, "include word audio, download it if not cached")
+	wordExportCmd.Flags().BoolVar(&wordExportFetch, "fetch", false, "look up words not in the cache online")
+	wordExtractCmd.Flags().StringVar(&wordExtractName, "name", "", "word set name (default is the file name)")
+	wordExtractCmd.Flags().StringVar(&wordExtractFormat, "format", "", "file format: plain, md, html or srt (default is detected from the file)")
+	wordExtractCmd.Flags().StringSliceVar(&wordExtractKnown, "known", nil, "file of known words, one word per line")
+	wordExtractCmd.Flags().IntVar(&wordExtractLimit, "limit", 0, "only keep the most frequent words, 0 for all")
+	wordExtractCmd.Flags().IntVar(&wordExtractMinCount, "min-count", 1, "only keep words appearing at least this many times")
+	wordExtractCmd.Flags().IntVar(&wordExtractMinLength, "min-length", 3, "ignore words shorter than this")
+	wordExtractCmd.Flags().BoolVar(&wordExtractDryRun, "dry-run", false, "only print the words and counts")
+	wordInstallCmd.Flags().StringVar(&wordInstallName, "name", "", "word set name (default is the builtin name)")
+	wordDedupeCmd.Flags().BoolVar(&wordDedupeLemma, "lemma", false, "also treat inflections like running and ran as duplicates of run")
+	wordDedupeCmd.Flags().BoolVar(&wordDedupeRemove, "remove", false, "keep each word only in the first given word set containing it")
+	wordShowCmd.Flags().StringVar(&wordShowSort, "sort", "alpha", "sort by alpha, correct or last")
+	wordShowCmd.Flags().StringVar(&wordShowState, "state", "", "only show words in state new, learning, review or remembered")
+	wordDeleteCmd.Flags().BoolVar(&wordDeleteForce, "force", false, "allow deleting the default word set")
+	wordCmd.AddCommand(wordListCmd)
+	wordCmd.AddCommand(wordShowCmd)
+	wordCmd.AddCommand(wordImportCmd)
+	wordCmd.AddCommand(wordExportCmd)
+	wordCmd.AddCommand(wordExtractCmd)
+	wordCmd.AddCommand(wordInstallCmd)
+	wordCmd.AddCommand(wordAddCmd)
+	wordCmd.AddCommand(wordRmCmd)
+	wordCmd.AddCommand(wordDeleteCmd)
+	wordCmd.AddCommand(wordRenameCmd)
+	wordCmd.AddCommand(wordMergeCmd)
+	wordCmd.AddCommand(wordIntersectCmd)
+	wordCmd.AddCommand(wordSubtractCmd)
+	wordCmd.AddCommand(wordDedupeCmd)
+	wordCmd.AddCommand(wordDiffCmd)
+	wordCmd.AddCommand(wordCopyCmd)
+	wordCmd.AddCommand(wordPrefetchCmd)
+
+	cachePruneCmd.Flags().BoolVar(&cachePruneAll, "all", false, "remove all cache entries")
+	cacheRefreshCmd.Flags().BoolVar(&cacheRefreshAll, "all", false, "refresh all cache entries")
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+	cacheCmd.AddCommand(cacheRefreshCmd)
+	cacheCmd.AddCommand(cacheExportCmd)
+	cacheCmd.AddCommand(cacheImportCmd)
+
+	historyCmd.Flags().StringVar(&historySince, "since", "", "only list lookups since a date like 2006-01-02 or a duration like 12h, 7d")
+	historyCmd.Flags().StringVar(&historyGrep, "grep", "", "only list words or queries matching this regular expression")
+	historyCmd.Flags().BoolVar(&historyCount, "count", false, "list words by lookup count")
+	noteCmd.Flags().StringVar(&noteTag, "tag", "", "only list words with this tag")
+	noteCmd.Flags().BoolVar(&noteStarred, "star", false, "only list starred words")
+
+	serveCmd.Flags().StringVar(&serveAddr, "addr", server.DefaultAddr, "localhost address to listen on")
+	lspCmd.Flags().StringVar(&lspWordSet, "wordset", wordset.DefaultWordSet, "word set the add to word set code action adds to")
+
+	daemonCmd.AddCommand(daemonStopCmd)
+	daemonCmd.AddCommand(daemonStatusCmd)
+
+	userdictCmd.AddCommand(userdictEditCmd)
+	userdictCmd.AddCommand(userdictImportCmd)
+	userdictCmd.AddCommand(userdictListCmd)
 
 	rootCmd.AddCommand(transCmd)
 	rootCmd.AddCommand(wordCmd)
 	rootCmd.AddCommand(practiceCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(noteCmd)
+	rootCmd.AddCommand(userdictCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(lspCmd)
 }
 
 func initConfig() {
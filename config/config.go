@@ -15,8 +15,60 @@ type Config struct {
 	StoragePath     string
 	FfplayPath      string
 	FfplayArgs      []string
+	AudioPlayer     string
+	AudioCommand    []string
+	VoiceAccent     string
+	VoiceGender     string
+	VoiceSpeed      float64
+	VoiceSources    []string
+	TTSCommand      []string
 	GroupNum        int
 	RestudyInterval map[int]int
+	CacheTTL        int
+	PrefetchWorkers int
+	KnownWords      []string
+	// 练习时单词的变形也算正确，例如 running 和 run
+	AcceptInflections bool
+	Promotion         Promotion
+	Provider          Provider
+}
+
+// Provider 为代替在线词典查询单词的外部命令，命令把 JSON 格式的单词写到标准输出
+type Provider struct {
+	// 每个 Provider 的缓存放在 StoragePath/providers/Name 下，默认为命令的文件名
+	Name string
+	// {word} 会被替换为查询的内容，没有 {word} 时加在最后
+	Command []string
+	// 以秒为单位，默认：10
+	Timeout int
+}
+
+// Promotion 为查询单词后加入单词本的规则，查询次数来自查询记录
+type Promotion struct {
+	// 查询次数达到后加入默认单词本，默认：1
+	DefaultLookups int
+	// 查询次数达到后加入 WordSet 并优先练习，默认：3，-1 时不加入
+	Lookups int
+	// 默认：troublesome
+	WordSet string
+	// 查询已记住的单词时不重置练习进度
+	KeepRemembered bool
+}
+
+const DefaultTroublesomeWordSet = "troublesome"
+
+// WithDefaults 返回设置了默认值的规则
+func (p Promotion) WithDefaults() Promotion {
+	if p.DefaultLookups <= 0 {
+		p.DefaultLookups = 1
+	}
+	if p.Lookups == 0 {
+		p.Lookups = 3
+	}
+	if p.WordSet == "" {
+		p.WordSet = DefaultTroublesomeWordSet
+	}
+	return p
 }
 
 var (
@@ -35,8 +87,12 @@ func init() {
 	DefaultConfigDir = path.Dir(DefaultConfigPath)
 	DefaultStorageDir = path.Join(xdg.DataHome, "idict")
 	DefaultConfig = Config{
-		StoragePath: DefaultStorageDir,
-		GroupNum:    20,
+		StoragePath:     DefaultStorageDir,
+		GroupNum:        20,
+		PrefetchWorkers: 4,
+		VoiceAccent:     "us",
+		VoiceGender:     "female",
+		VoiceSpeed:      1,
 		RestudyInterval: map[int]int{
 			3:  0,
 			5:  12,
@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"syscall"
+
+	idictconfig "github.com/lai323/idict/config"
+	"github.com/lai323/idict/dict"
+	"github.com/lai323/idict/server"
+	"github.com/lai323/idict/wordset"
+	"github.com/spf13/cobra"
+)
+
+// Handler 为后台服务的接口，与 idict serve 相同，另外可以通过 POST /shutdown 停止服务
+func Handler(cli dict.DictClient, m wordset.WordSetManage, shutdown func()) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", server.Server{Client: cli, Manage: m}.Handler())
+	mux.HandleFunc("/shutdown", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		shutdown()
+	})
+	return mux
+}
+
+// Listen 监听 Unix socket，服务已经在运行时返回错误，上次没有正常退出留下的 socket 会被删除
+func Listen(socket string) (net.Listener, error) {
+	if dict.NewSocketClient(socket).Alive() {
+		return nil, fmt.Errorf("daemon already running at %s", socket)
+	}
+	err := os.Remove(socket)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	// socket 创建时的权限受 umask 影响，放在只有自己可以访问的目录中，创建后其他用户也无法连接
+	dir := path.Dir(socket)
+	err = os.MkdirAll(dir, 0700)
+	if err == nil {
+		err = os.Chmod(dir, 0700)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	err = os.Chmod(socket, 0600)
+	if err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// Run 在前台运行后台服务，收到 SIGINT SIGTERM 或者 idict daemon stop 时退出
+func Run(config *idictconfig.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if config.StoragePath == "" {
+			return errors.New("StoragePath empty")
+		}
+		cli, err := dict.NewEuDictClient(*config)
+		if err != nil {
+			return err
+		}
+		socket := dict.SocketPath(config.StoragePath)
+		ln, err := Listen(socket)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		m := wordset.WordSetManage{StoragePath: config.StoragePath, RestudyInterval: config.RestudyInterval}
+		fmt.Printf("listening on %s\n", socket)
+		return server.Run(ctx, ln, Handler(cli, m, stop))
+	}
+}
+
+func Stop(config *idictconfig.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		c := dict.NewSocketClient(dict.SocketPath(config.StoragePath))
+		if !c.Alive() {
+			return errors.New("daemon not running")
+		}
+		return c.Shutdown()
+	}
+}
+
+func Status(config *idictconfig.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		socket := dict.SocketPath(config.StoragePath)
+		if dict.NewSocketClient(socket).Alive() {
+			fmt.Printf("running at %s\n", socket)
+		} else {
+			fmt.Println("not running")
+		}
+		return nil
+	}
+}
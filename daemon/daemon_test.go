@@ -0,0 +1,105 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lai323/idict/dict"
+	"github.com/lai323/idict/server"
+	"github.com/lai323/idict/wordset"
+)
+
+type fakeClient struct{}
+
+func (fakeClient) Fetch(text string) (error, wordset.Word) { return fakeClient{}.Cache(text) }
+func (fakeClient) FetchCache(text string) (error, wordset.Word) {
+	return fakeClient{}.Cache(text)
+}
+func (fakeClient) Guess(text string) (error, []wordset.GuessWord) {
+	return nil, []wordset.GuessWord{{Value: text + "le"}}
+}
+func (fakeClient) Cache(text string) (error, wordset.Word) {
+	if text == "missing" {
+		return errors.New("not found"), wordset.Word{}
+	}
+	return nil, wordset.Word{Text: text, Translates: []wordset.Translate{{Mean: "苹果"}}}
+}
+
+func TestDaemon(t *testing.T) {
+	// Unix socket 的路径长度有限制，不使用 t.TempDir
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := dict.SocketPath(dir)
+
+	// 上次没有正常退出留下的 socket，目录权限过宽时会被修正
+	err = os.MkdirAll(path.Dir(socket), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(socket, nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := Listen(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path.Dir(socket)); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("socket dir %v %v", info.Mode(), err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := wordset.WordSetManage{StoragePath: dir}
+	done := make(chan error, 1)
+	go func() { done <- server.Run(ctx, ln, Handler(fakeClient{}, m, cancel)) }()
+
+	if _, err := Listen(socket); err == nil || !strings.Contains(err.Error(), "already running") {
+		t.Errorf("listen twice %v", err)
+	}
+
+	c := dict.NewSocketClient(socket)
+	if !c.Alive() {
+		t.Fatal("not alive")
+	}
+	err, word := c.Cache("apple")
+	if err != nil || word.Text != "apple" || word.Translates[0].Mean != "苹果" {
+		t.Errorf("cache %+v %v", word, err)
+	}
+	if err, _ := c.FetchCache("missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing %v", err)
+	}
+	if _, words := c.Guess("app"); len(words) != 1 || words[0].Value != "apple" {
+		t.Errorf("guess %v", words)
+	}
+
+	err = c.Shutdown()
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("run %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("not stopped")
+	}
+	if c.Alive() {
+		t.Error("alive after shutdown")
+	}
+	if err, _ := c.Guess("app"); err == nil {
+		t.Error("guess without daemon returned no error")
+	}
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Errorf("socket not removed %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package dict
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lai323/idict/config"
+	"github.com/lai323/idict/wordset"
+)
+
+const defaultProviderTimeout = 10 * time.Second
+
+// CommandClient 运行外部命令查询单词，命令把 wordset.Word 格式的 JSON 写到标准输出，
+// 键不区分大小写，例如 {"text": "apple", "translates": [{"part": "n.", "mean": "苹果"}]}
+// 查询失败时以非 0 状态退出并把原因写到标准错误，或者输出 {"error": "..."}
+type CommandClient struct {
+	Name    string
+	Command []string
+	Timeout time.Duration
+}
+
+func NewCommandClient(p config.Provider) CommandClient {
+	c := CommandClient{Name: p.Name, Command: p.Command, Timeout: time.Duration(p.Timeout) * time.Second}
+	if c.Name == "" && len(c.Command) != 0 {
+		base := filepath.Base(c.Command[0])
+		c.Name = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultProviderTimeout
+	}
+	return c
+}
+
+// commandArgs 把参数中的 {word} 替换为查询的内容，没有 {word} 时加在最后
+func commandArgs(args []string, text string) []string {
+	var (
+		expanded []string
+		replaced bool
+	)
+	for _, arg := range args {
+		if strings.Contains(arg, "{word}") {
+			replaced = true
+			arg = strings.Replace(arg, "{word}", text, -1)
+		}
+		expanded = append(expanded, arg)
+	}
+	if !replaced {
+		expanded = append(expanded, text)
+	}
+	return expanded
+}
+
+func (c CommandClient) Fetch(text string) (error, wordset.Word) {
+	word := wordset.Word{Text: wordset.NormalizeText(text)}
+	if word.Text == "" {
+		return nil, word
+	}
+	if len(c.Command) == 0 {
+		return fmt.Errorf("Provider %s command empty", c.Name), word
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+	stdout, stderr, err := runCommand(ctx, c.Command[0], commandArgs(c.Command[1:], word.Text))
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("Provider %s timeout after %s", c.Name, c.Timeout), word
+	}
+	if err != nil {
+		msg := strings.TrimSpace(string(stderr))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("Provider %s %s", c.Name, msg), word
+	}
+
+	var out struct {
+		wordset.Word
+		Error string
+	}
+	err = json.Unmarshal(stdout, &out)
+	if err != nil {
+		return fmt.Errorf("Provider %s decode %s", c.Name, err.Error()), word
+	}
+	if out.Error != "" {
+		return fmt.Errorf("Provider %s %s", c.Name, out.Error), word
+	}
+	if out.Text == "" {
+		out.Text = word.Text
+	}
+	out.Text = wordset.NormalizeText(out.Text)
+	for i := range out.Phrases {
+		if out.Phrases[i].Word == "" {
+			out.Phrases[i].Word = out.Text
+		}
+	}
+	for i := range out.Sentences {
+		if out.Sentences[i].Word == "" {
+			out.Sentences[i].Word = out.Text
+		}
+	}
+	return nil, out.Word
+}
+
+// runCommand 运行命令并返回标准输出和标准错误，超时后结束命令
+// 输出写到临时文件而不是管道，命令被结束后不需要等待仍然持有管道的子进程
+func runCommand(ctx context.Context, name string, args []string) ([]byte, []byte, error) {
+	var files [2]*os.File
+	for i := range files {
+		f, err := ioutil.TempFile("", "idict-provider-")
+		if err != nil {
+			return nil, nil, err
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		files[i] = f
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = files[0]
+	cmd.Stderr = files[1]
+	err := cmd.Run()
+
+	var output [2][]byte
+	for i, f := range files {
+		b, readErr := ioutil.ReadFile(f.Name())
+		if readErr != nil {
+			return nil, nil, readErr
+		}
+		output[i] = b
+	}
+	return output[0], output[1], err
+}
+
+func (c CommandClient) FetchCache(text string) (error, wordset.Word) {
+	return c.Fetch(text)
+}
+
+func (c CommandClient) Cache(text string) (error, wordset.Word) {
+	return c.Fetch(text)
+}
+
+// Guess 外部命令不提供联想词
+func (c CommandClient) Guess(text string) (error, []wordset.GuessWord) {
+	return nil, nil
+}
@@ -0,0 +1,112 @@
+package dict
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lai323/idict/config"
+	"github.com/lai323/idict/wordset"
+)
+
+func TestCommandClient(t *testing.T) {
+	c := NewCommandClient(config.Provider{Command: []string{"testdata/glossary.sh", "{word}"}})
+	if c.Name != "glossary" || c.Timeout != defaultProviderTimeout {
+		t.Errorf("defaults %+v", c)
+	}
+	err, word := c.Fetch(" Idempotent ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if word.Text != "idempotent" || word.PronounceUS.Phonetic != "/ˌaɪdəmˈpoʊtənt/" || word.Translates[0].Mean != "幂等的" ||
+		len(word.Sentences) != 1 || word.Sentences[0].Word != "idempotent" {
+		t.Errorf("word %+v", word)
+	}
+	if err, word := c.Fetch("sharding"); err != nil || word.Text != "sharding" || word.Translates[0].Mean != "分片" {
+		t.Errorf("sharding %+v %v", word, err)
+	}
+
+	for name, cmd := range map[string][]string{
+		"not found: foo": {"testdata/glossary.sh"},
+		"offline":        {"sh", "-c", `echo '{"error": "offline"}'`},
+		"decode":         {"echo", "not json"},
+		"no such file":   {"testdata/missing.sh"},
+	} {
+		c := NewCommandClient(config.Provider{Name: "test", Command: cmd})
+		err, _ := c.Fetch("foo")
+		if err == nil || !strings.Contains(err.Error(), name) || !strings.HasPrefix(err.Error(), "Provider test ") {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	c = NewCommandClient(config.Provider{Name: "slow", Command: []string{"sh", "-c", "sleep 5; echo"}})
+	c.Timeout = 100 * time.Millisecond
+	start := time.Now()
+	err, _ = c.Fetch("foo")
+	if err == nil || !strings.Contains(err.Error(), "timeout") || time.Since(start) > 2*time.Second {
+		t.Errorf("timeout %v after %s", err, time.Since(start))
+	}
+}
+
+func TestCommandArgs(t *testing.T) {
+	if args := commandArgs([]string{"--word={word}", "-j"}, "apple"); !reflect.DeepEqual(args, []string{"--word=apple", "-j"}) {
+		t.Errorf("placeholder %v", args)
+	}
+	if args := commandArgs([]string{"-j"}, "apple"); !reflect.DeepEqual(args, []string{"-j", "apple"}) {
+		t.Errorf("append %v", args)
+	}
+}
+
+func TestEuDictClientProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cli, err := NewEuDictClient(config.Config{
+		StoragePath: dir,
+		Provider:    config.Provider{Command: []string{"testdata/glossary.sh"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err, word := cli.Cache("sharding")
+	if err != nil || word.Translates[0].Mean != "分片" {
+		t.Fatalf("cache %+v %v", word, err)
+	}
+	// 没有音标的词条也会缓存
+	entry, exist, err := cli.WordCache().Entry("sharding")
+	if err != nil || !exist || entry.Meta.Provider != "glossary" {
+		t.Errorf("entry %+v %v %v", entry.Meta, exist, err)
+	}
+	if err, _ := cli.Cache("foo"); err == nil || !strings.Contains(err.Error(), "not found: foo") {
+		t.Errorf("missing %v", err)
+	}
+	// 外部命令的词条没有音标时也加入默认单词本
+	if err, _ := cli.FetchCache("sharding"); err != nil {
+		t.Fatal(err)
+	}
+	ws, err := wordset.WordSetManage{StoragePath: dir}.Load(wordset.DefaultWordSet)
+	if _, ok := ws.Words["sharding"]; err != nil || !ok {
+		t.Errorf("provider word not promoted %v %v", ws.Words, err)
+	}
+
+	// 每个来源使用单独的缓存
+	eu, err := NewEuDictClient(config.Config{StoragePath: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exist, err := eu.WordCache().Entry("sharding"); err != nil || exist {
+		t.Errorf("provider entry in eudic cache %v %v", exist, err)
+	}
+	if dir := cli.WordCache().CacheDir(); !strings.HasSuffix(dir, "/providers/glossary/wordcache") {
+		t.Errorf("provider cache dir %s", dir)
+	}
+	_, err = NewEuDictClient(config.Config{StoragePath: dir, Provider: config.Provider{Name: "../x", Command: []string{"true"}}})
+	if err == nil {
+		t.Error("invalid provider name accepted")
+	}
+}
@@ -13,9 +13,12 @@ import (
 	// "os"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/antchfx/htmlquery"
+	"github.com/lai323/idict/audio"
 	"github.com/lai323/idict/config"
+	"github.com/lai323/idict/lemma"
 	"github.com/lai323/idict/wordset"
 	"github.com/muesli/termenv"
 	"golang.org/x/net/html"
@@ -24,16 +27,29 @@ import (
 
 var term = termenv.ColorProfile()
 
+const (
+	EuProvider = "eudic"
+	// 修改 Fetch 的解析逻辑后需要增加版本号，旧版本解析的缓存会被重新获取
+	EuParserVersion = 1
+)
+
 type DictClient interface {
 	Fetch(string) (error, wordset.Word)
 	Guess(string) (error, []wordset.GuessWord)
 	FetchCache(string) (error, wordset.Word)
+	Cache(string) (error, wordset.Word)
 }
 
 type EuDictClient struct {
 	config         config.Config
 	wordcache      wordset.WordCache
 	defaultWordset wordset.WordSet
+	suggester      *suggester
+	history        wordset.History
+	userdict       *userDictLoader
+	promoter       *promoter
+	// 设置后代替在线词典查询单词
+	provider *CommandClient
 }
 
 func NewEuDictClient(config config.Config) (EuDictClient, error) {
@@ -48,51 +64,174 @@ func NewEuDictClient(config config.Config) (EuDictClient, error) {
 		return cli, err
 	}
 
-	wordcache, err := wordset.NewWordCache(config.StoragePath)
+	var wordcache wordset.WordCache
+	if len(config.Provider.Command) != 0 {
+		provider := NewCommandClient(config.Provider)
+		cli.provider = &provider
+		wordcache, err = wordset.NewProviderWordCache(config.StoragePath, provider.Name)
+	} else {
+		wordcache, err = wordset.NewWordCache(config.StoragePath)
+		wordcache.Provider = EuProvider
+		wordcache.ParserVersion = EuParserVersion
+	}
 	if err != nil {
 		return cli, err
 	}
+	wordcache.TTL = time.Duration(config.CacheTTL) * time.Hour
 	cli.config = config
 	cli.wordcache = wordcache
 	cli.defaultWordset = defaultWordset
+	cli.history = wordset.NewHistory(config.StoragePath)
+	cli.userdict = &userDictLoader{storagePath: config.StoragePath}
+	cli.suggester = &suggester{wordcache: wordcache, wordsetDir: defaultWordset.StorageDir}
+	cli.promoter = newPromoter(config.StoragePath, config.RestudyInterval, cli.lemmatizer().Lemma)
 	return cli, err
 }
 
 func (d EuDictClient) FetchCache(text string) (error, wordset.Word) {
-	word, exist, err := d.wordcache.Get(text)
+	err, word := d.Cache(text)
 	if err != nil {
 		return err, word
 	}
+	if word.PronounceUS.Phonetic != "" || len(word.Translates) != 0 {
+		err = d.history.Add(word.Text, text)
+		if err != nil {
+			return err, word
+		}
+	}
+	err = d.promote(word)
 	if err != nil {
 		return err, word
 	}
-	if exist {
-		if word.PronounceUS.Phonetic != "" {
-			err = d.defaultWordset.Append(word.Text)
-			if err != nil {
-				return err, word
-			}
+	if d.suggester != nil {
+		d.suggester.add(word)
+	}
+	return nil, word
+}
+
+// History 返回客户端的查询记录
+func (d EuDictClient) History() wordset.History {
+	return d.history
+}
+
+// known 判断单词是否在默认单词本或者缓存中，不联网
+func (d EuDictClient) known(text string) bool {
+	if _, ok := d.defaultWordset.Words[text]; ok {
+		return true
+	}
+	word, exist, err := d.wordcache.Get(text)
+	return err == nil && exist && len(word.Translates) != 0
+}
+
+// lemmatizer 只把单词还原为常用单词或者已知的单词
+func (d EuDictClient) lemmatizer() lemma.Lemmatizer {
+	return lemma.Lemmatizer{Known: d.known}
+}
+
+// lemma 返回加入默认单词本的原形，例如查询 running 时加入 run
+// 原形需要能查询到翻译，避免规则错误时加入不存在的单词
+func (d EuDictClient) lemma(text string) string {
+	text = wordset.NormalizeWord(text)
+	l := d.lemmatizer().Lemma(text)
+	if l == text {
+		return text
+	}
+	err, word := d.Cache(l)
+	if err != nil || len(word.Translates) == 0 || !d.isWord(word) {
+		return text
+	}
+	return l
+}
+
+// isWord 判断查询结果是否为单词：在线词典中有音标，或者自己的词典和外部命令中有翻译，
+// 在线词典对句子和不存在的单词也会给出机器翻译，但是没有音标
+func (d EuDictClient) isWord(word wordset.Word) bool {
+	if word.PronounceUS.Phonetic != "" {
+		return true
+	}
+	if len(word.Translates) == 0 {
+		return false
+	}
+	if d.provider != nil {
+		return true
+	}
+	userdict, err := d.userdict.get()
+	if err != nil {
+		return false
+	}
+	_, ok := userdict.Get(word.Text)
+	return ok
+}
+
+// Cache 与 FetchCache 相同，但不会把单词加入默认单词本
+// 先查找自己的词典，自己的词条完整时不查询词典，否则合并到词典的结果中
+func (d EuDictClient) Cache(text string) (error, wordset.Word) {
+	userdict, err := d.userdict.get()
+	if err != nil {
+		return err, wordset.Word{}
+	}
+	entry, ok := userdict.Get(text)
+	if ok && entry.Complete() {
+		return nil, entry.Merge(wordset.Word{Text: wordset.NormalizeWord(text)})
+	}
+	err, word := d.cache(text)
+	if err != nil {
+		// 词典中没有的单词只使用自己的词条
+		if ok {
+			return nil, entry.Merge(wordset.Word{Text: wordset.NormalizeWord(text)})
 		}
-		return nil, word
+		return err, word
+	}
+	return nil, userdict.Merge(word)
+}
+
+func (d EuDictClient) cache(text string) (error, wordset.Word) {
+	entry, exist, err := d.wordcache.Entry(text)
+	if err != nil {
+		return err, entry.Word
+	}
+	if exist && !d.wordcache.Expired(entry) {
+		return nil, entry.Word
 	}
 
-	err, word = d.Fetch(text)
+	err, word := d.Refresh(text)
 	if err != nil {
+		// 无法获取时使用过期的缓存
+		if exist {
+			return nil, entry.Word
+		}
 		return err, word
 	}
-	if word.PronounceUS.Phonetic != "" {
+	return nil, word
+}
+
+// Refresh 忽略缓存重新获取，并更新缓存
+func (d EuDictClient) Refresh(text string) (error, wordset.Word) {
+	err, word := d.Fetch(text)
+	if err != nil {
+		return err, word
+	}
+	// 外部命令的词条可能没有音标
+	if word.PronounceUS.Phonetic != "" || (d.provider != nil && len(word.Translates) != 0) {
 		err = d.wordcache.Set(word)
-		if err != nil {
-			return err, word
-		}
-		err = d.defaultWordset.Append(word.Text)
-		if err != nil {
-			return err, word
-		}
 	}
 	return err, word
 }
 
+// 获取单词时记录使用的发音
+func (d EuDictClient) voice(accent string) string {
+	voice, err := audio.VoiceName(accent, d.config.VoiceGender)
+	if err != nil {
+		voice, _ = audio.VoiceName(accent, "")
+	}
+	return voice
+}
+
+// WordCache 返回客户端使用的缓存
+func (d EuDictClient) WordCache() wordset.WordCache {
+	return d.wordcache
+}
+
 func (d EuDictClient) Fetch(text string) (error, wordset.Word) {
 	var (
 		err       error
@@ -103,6 +242,9 @@ func (d EuDictClient) Fetch(text string) (error, wordset.Word) {
 	if text == "" {
 		return err, word
 	}
+	if d.provider != nil {
+		return d.provider.Fetch(text)
+	}
 
 	word.Text = strings.TrimSpace(strings.ToLower(text))
 	resp, err := euquery(text)
@@ -145,6 +287,8 @@ func (d EuDictClient) Fetch(text string) (error, wordset.Word) {
 		}
 		word.PronounceUK = wordset.Pronounce{Phonetic: phoneticUK}
 	}
+	word.PronounceUS.Voice = d.voice("us")
+	word.PronounceUK.Voice = d.voice("uk")
 
 	translatelist := htmlquery.Find(doc, `//div[@id="ExpFCChild"]/ol/li`)
 	for _, trans := range translatelist {
@@ -268,7 +412,8 @@ func (d EuDictClient) Fetch(text string) (error, wordset.Word) {
 	return err, word
 }
 
-func (d EuDictClient) Guess(text string) (error, []wordset.GuessWord) {
+// remoteGuess 获取在线的联想词，出错时返回空
+func remoteGuess(text string) (error, []wordset.GuessWord) {
 	var (
 		guesses []wordset.GuessWord
 		err     error
@@ -277,7 +422,7 @@ func (d EuDictClient) Guess(text string) (error, []wordset.GuessWord) {
 		return err, guesses
 	}
 	text = strings.TrimSpace(text)
-	resp, err := http.Get("https://dict.eudic.net/dicts/prefix/" + text)
+	resp, err := guessClient.Get("https://dict.eudic.net/dicts/prefix/" + url.PathEscape(text))
 	if err != nil {
 		// 忽略这个异常
 		// return utils.FmtErrorf("guess word error http get", err), guesses
@@ -324,9 +469,10 @@ func euquery(text string) (*http.Response, error) {
 		err  error
 		resp *http.Response
 	)
-	url := fmt.Sprintf("https://dict.eudic.net/dicts/en/%s", text)
+	// 词组中有空格，需要转义
+	queryurl := fmt.Sprintf("https://dict.eudic.net/dicts/en/%s", url.PathEscape(text))
 	client := &http.Client{}
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequest("GET", queryurl, nil)
 	if err != nil {
 		return resp, err
 	}
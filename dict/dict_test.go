@@ -4,7 +4,15 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/url"
+	"os"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/lai323/idict/audio"
+	"github.com/lai323/idict/config"
+	"github.com/lai323/idict/suggest"
+	"github.com/lai323/idict/wordset"
 )
 
 func TestEuDictGuess(t *testing.T) {
@@ -13,6 +21,53 @@ func TestEuDictGuess(t *testing.T) {
 	fmt.Println(words, err)
 }
 
+func TestMergeGuess(t *testing.T) {
+	remote := []wordset.GuessWord{{Value: "apple"}, {Value: "Apple"}, {Value: "apply"}}
+	local := []suggest.Entry{{Word: "apple"}, {Word: "appeal", Label: "n. 呼吁"}}
+	guesses := mergeGuess(remote, local, 10)
+	if len(guesses) != 3 || guesses[2].Value != "appeal" || guesses[2].Label != "n. 呼吁" {
+		t.Errorf("merge %v", guesses)
+	}
+	// 在线的结果较多时仍然保留本地的结果
+	guesses = mergeGuess(remote, local, 2)
+	if len(guesses) != 3 {
+		t.Errorf("limit %v", guesses)
+	}
+}
+
+func TestSuggester(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache, err := wordset.NewWordCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 单词本目录无法读取时返回错误，之后使用部分索引
+	file := dir + "/wordset"
+	err = ioutil.WriteFile(file, nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &suggester{wordcache: cache, wordsetDir: file}
+	s.add(wordset.Word{Text: "idempotent", Translates: []wordset.Translate{{Mean: "幂等的"}}})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.add(wordset.Word{Text: "apple", Translates: []wordset.Translate{{Mean: "苹果"}}})
+	}()
+	if _, err := s.suggest("the"); err == nil {
+		t.Error("build error not returned")
+	}
+	<-done
+	if entries, err := s.suggest("the"); err != nil || len(entries) == 0 {
+		t.Errorf("partial index %v %v", entries, err)
+	}
+}
+
 func TestEuDictFetch(t *testing.T) {
 	cli := EuDictClient{}
 	err, word := cli.Fetch("guess")
@@ -37,3 +92,156 @@ func testurl() {
 	params.Add("phone", "+919999999999")
 	fmt.Println(params.Encode())
 }
+
+func TestFetchCacheLemma(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cli, err := NewEuDictClient(config.Config{StoragePath: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 缓存中的单词不需要联网
+	for _, text := range []string{"run", "running", "lens"} {
+		err = cli.WordCache().Set(wordset.Word{
+			Text:        text,
+			PronounceUS: wordset.Pronounce{Phonetic: "/" + text + "/"},
+			Translates:  []wordset.Translate{{Mean: text}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = cli.WordCache().Set(wordset.Word{Text: "len"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, text := range []string{"running", "lens"} {
+		err, _ = cli.FetchCache(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ws, err := wordset.NewWordSet(wordset.DefaultWordSet, wordset.WordSetManage{StoragePath: dir}.WordSetDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ws.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ws.Words["run"]; !ok || len(ws.Words) != 2 {
+		t.Errorf("default word set: %v", ws.Words)
+	}
+	if _, ok := ws.Words["lens"]; !ok {
+		t.Errorf("lens without lemma translation not kept: %v", ws.Words)
+	}
+	counts, err := cli.History().Counts()
+	if err != nil || counts["running"] != 1 || counts["lens"] != 1 || counts["run"] != 0 {
+		t.Errorf("history %v %v", counts, err)
+	}
+}
+
+func TestPushNav(t *testing.T) {
+	m := DictModel{nav: []string{"apple", "pear"}, navpos: 2}
+	m.pushNav("pear")
+	if m.navpos != 1 || len(m.nav) != 2 {
+		t.Errorf("same as last %v %d", m.nav, m.navpos)
+	}
+	m.navpos = 0
+	m.pushNav("run")
+	if strings.Join(m.nav, ",") != "apple,run" || m.navpos != 1 {
+		t.Errorf("forward not dropped %v %d", m.nav, m.navpos)
+	}
+}
+
+func TestVoiceError(t *testing.T) {
+	m := DictModel{}
+	msg := m.speakCmd("hello", "")()
+	if msg.(VoiceMsg).Err != audio.ErrNoPlayer {
+		t.Fatalf("msg %+v", msg)
+	}
+	model, _ := m.Update(msg)
+	if status := model.(DictModel).status; status != "voice: no audio player configured" {
+		t.Errorf("status %q", status)
+	}
+}
+
+func TestNavHistoryError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	m := DictModel{cli: &fakeClient{}, nav: []string{"broken", "apple"}, navpos: 1}
+	model, _ := m.Update(m.navCmd(-1)())
+	if status := model.(DictModel).status; status != "lookup: network down" {
+		t.Errorf("nav status %q", status)
+	}
+	// 查询记录无法读取
+	err = os.Mkdir(wordset.HistoryFile(dir), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.history = wordset.NewHistory(dir)
+	model, _ = m.Update(m.historyCmd()())
+	if status := model.(DictModel).status; !strings.HasPrefix(status, "history: ") {
+		t.Errorf("history status %q", status)
+	}
+}
+
+func TestCacheUserDict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cli, err := NewEuDictClient(config.Config{StoragePath: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cli.WordCache().Set(wordset.Word{
+		Text:        "apple",
+		PronounceUS: wordset.Pronounce{Phonetic: "/ˈæpl/"},
+		Translates:  []wordset.Translate{{Part: "n.", Mean: "苹果"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(wordset.UserDictFile(dir), []byte("apple:\n  translates:\n  - mean: 苹果公司\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err, word := cli.Cache("apple")
+	if err != nil || len(word.Translates) != 2 || word.Translates[0].Mean != "苹果公司" {
+		t.Errorf("merged %+v %v", word, err)
+	}
+
+	// 修改后重新读取，完整的词条不需要联网
+	err = ioutil.WriteFile(wordset.UserDictFile(dir), []byte("idempotent:\n  replace: true\n  translates:\n  - mean: 幂等的\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(wordset.UserDictFile(dir), time.Now().Add(time.Second), time.Now().Add(time.Second))
+	err, word = cli.Cache("idempotent")
+	if err != nil || len(word.Translates) != 1 || word.Text != "idempotent" {
+		t.Errorf("user entry %+v %v", word, err)
+	}
+	err, word = cli.Cache("apple")
+	if err != nil || len(word.Translates) != 1 {
+		t.Errorf("reloaded %+v %v", word, err)
+	}
+
+	// 自己的词条没有音标时也记录查询并加入默认单词本
+	err, _ = cli.FetchCache("idempotent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws, err := wordset.WordSetManage{StoragePath: dir}.Load(wordset.DefaultWordSet)
+	if _, ok := ws.Words["idempotent"]; err != nil || !ok {
+		t.Errorf("userdict word not promoted %v %v", ws.Words, err)
+	}
+}
@@ -0,0 +1,126 @@
+package dict
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/progress"
+	idictconfig "github.com/lai323/idict/config"
+	"github.com/lai323/idict/wordset"
+)
+
+const defaultPrefetchWorkers = 4
+
+type PrefetchResult struct {
+	Total int
+	// 获取成功但没有任何翻译的单词
+	Empty  []string
+	Failed map[string]error
+}
+
+// FirstError 返回按单词顺序第一个失败的单词和原因
+func (r PrefetchResult) FirstError() string {
+	var texts []string
+	for text := range r.Failed {
+		texts = append(texts, text)
+	}
+	if len(texts) == 0 {
+		return ""
+	}
+	sort.Strings(texts)
+	return fmt.Sprintf("%s: %s", texts[0], r.Failed[texts[0]].Error())
+}
+
+// Prefetch 以最多 workers 个并发把 words 写入缓存，已缓存且未过期的单词不会重新获取
+// progress 在每个单词完成后调用，可以为 nil
+func Prefetch(cli DictClient, words []string, workers int, progress func(done, total int, text string)) PrefetchResult {
+	result := PrefetchResult{Total: len(words), Failed: map[string]error{}}
+	if workers <= 0 {
+		workers = defaultPrefetchWorkers
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		done int
+	)
+	texts := make(chan string)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for text := range texts {
+				err, word := cli.Cache(text)
+
+				mu.Lock()
+				if err != nil {
+					result.Failed[text] = err
+				} else if len(word.Translates) == 0 {
+					result.Empty = append(result.Empty, text)
+				}
+				done++
+				if progress != nil {
+					progress(done, len(words), text)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, text := range words {
+		texts <- text
+	}
+	close(texts)
+	wg.Wait()
+
+	sort.Strings(result.Empty)
+	return result
+}
+
+// StartPrefetch 预先获取整个单词本，用于离线练习
+func StartPrefetch(config *idictconfig.Config) func(string) error {
+	return func(name string) error {
+		ws, err := wordset.WordSetManage{StoragePath: config.StoragePath}.Load(name)
+		if err != nil {
+			return err
+		}
+
+		cli, err := NewEuDictClient(*config)
+		if err != nil {
+			return err
+		}
+
+		var words []string
+		for w := range ws.Words {
+			words = append(words, w)
+		}
+		sort.Strings(words)
+
+		bar, err := progress.NewModel(progress.WithDefaultGradient(), progress.WithWidth(50))
+		if err != nil {
+			return err
+		}
+		result := Prefetch(cli, words, config.PrefetchWorkers, func(done, total int, text string) {
+			fmt.Printf("\r%s %d/%d", bar.View(float64(done)/float64(total)), done, total)
+		})
+		fmt.Println()
+
+		fmt.Printf("prefetched %d words, %d failed, %d without translation\n",
+			result.Total-len(result.Failed), len(result.Failed), len(result.Empty))
+		var failed []string
+		for text := range result.Failed {
+			failed = append(failed, text)
+		}
+		sort.Strings(failed)
+		for _, text := range failed {
+			fmt.Printf("failed: %s: %s\n", text, result.Failed[text])
+		}
+		for _, text := range result.Empty {
+			fmt.Printf("no translation: %s\n", text)
+		}
+		if len(failed) != 0 {
+			return fmt.Errorf("%d words failed to prefetch", len(failed))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,57 @@
+package dict
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lai323/idict/wordset"
+)
+
+type fakeClient struct {
+	calls int32
+}
+
+func (c *fakeClient) Fetch(text string) (error, wordset.Word) {
+	return c.Cache(text)
+}
+
+func (c *fakeClient) Guess(text string) (error, []wordset.GuessWord) {
+	return nil, nil
+}
+
+func (c *fakeClient) FetchCache(text string) (error, wordset.Word) {
+	return c.Cache(text)
+}
+
+func (c *fakeClient) Cache(text string) (error, wordset.Word) {
+	atomic.AddInt32(&c.calls, 1)
+	switch text {
+	case "broken":
+		return errors.New("network down"), wordset.Word{}
+	case "empty":
+		return nil, wordset.Word{Text: text}
+	}
+	return nil, wordset.Word{Text: text, Translates: []wordset.Translate{{Mean: text}}}
+}
+
+func TestPrefetch(t *testing.T) {
+	cli := &fakeClient{}
+	words := []string{"a", "b", "broken", "empty", "c"}
+	last := 0
+	result := Prefetch(cli, words, 2, func(done, total int, text string) {
+		if done != last+1 || total != len(words) {
+			t.Errorf("progress %d/%d after %d", done, total, last)
+		}
+		last = done
+	})
+	if int(cli.calls) != len(words) || last != len(words) {
+		t.Errorf("calls %d progress %d", cli.calls, last)
+	}
+	if len(result.Failed) != 1 || result.Failed["broken"] == nil {
+		t.Errorf("Failed %v", result.Failed)
+	}
+	if len(result.Empty) != 1 || result.Empty[0] != "empty" {
+		t.Errorf("Empty %v", result.Empty)
+	}
+}
@@ -0,0 +1,144 @@
+package dict
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lai323/idict/wordset"
+)
+
+// promoter 缓存每个原形的查询次数和练习进度，避免每次查询都重新读取整个查询记录
+type promoter struct {
+	storagePath string
+	interval    map[int]int
+	lemma       func(string) string
+
+	mu sync.Mutex
+	// 查询记录只追加，从上次读取的位置继续读取
+	offset int64
+	words  map[string]int
+	lemmas map[string]int
+
+	extent        wordset.PracExtent
+	extentModTime time.Time
+	extentLoaded  bool
+}
+
+func newPromoter(storagePath string, interval map[int]int, lemma func(string) string) *promoter {
+	return &promoter{storagePath: storagePath, interval: interval, lemma: lemma}
+}
+
+// lookups 返回查询记录中这个单词和它的变形被查询的次数
+func (p *promoter) lookups(text, lemmaText string) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// 文件被截断或替换时重新统计
+	info, err := os.Stat(wordset.HistoryFile(p.storagePath))
+	if p.words == nil || err == nil && info.Size() < p.offset {
+		p.offset, p.words, p.lemmas = 0, map[string]int{}, map[string]int{}
+	}
+	entries, offset, err := wordset.NewHistory(p.storagePath).EntriesFrom(p.offset)
+	if err != nil {
+		return 0, err
+	}
+	p.offset = offset
+	for _, e := range entries {
+		p.words[e.Word]++
+		p.lemmas[p.lemma(e.Word)]++
+	}
+
+	count := p.lemmas[lemmaText]
+	if p.lemma(text) != lemmaText {
+		count += p.words[text]
+	}
+	if lemmaText != text && p.lemma(lemmaText) != lemmaText {
+		count += p.words[lemmaText]
+	}
+	return count, nil
+}
+
+// resetRemembered 重置已经记住的单词的练习进度，练习进度在其他进程中修改后重新读取
+func (p *promoter) resetRemembered(text string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	file := wordset.PracExtentFile(p.storagePath)
+	var modTime time.Time
+	info, err := os.Stat(file)
+	if err == nil {
+		modTime = info.ModTime()
+	}
+	if !p.extentLoaded || !modTime.Equal(p.extentModTime) {
+		p.extent, err = wordset.NewPracExtent(file, p.interval)
+		if err != nil {
+			return err
+		}
+		p.extentModTime, p.extentLoaded = modTime, true
+	}
+	if !p.extent.Remembered(text) {
+		return nil
+	}
+	err = p.extent.Reset(text)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(file); err == nil {
+		p.extentModTime = info.ModTime()
+	}
+	return nil
+}
+
+// promote 按查询次数把单词的原形加入默认单词本，查询多次的单词加入 troublesome 单词本优先练习
+// 查询已经记住的单词说明已经忘记，会重置练习进度
+func (d EuDictClient) promote(word wordset.Word) error {
+	if !d.isWord(word) || d.promoter == nil {
+		return nil
+	}
+	rule := d.config.Promotion.WithDefaults()
+	text := d.lemma(word.Text)
+	count, err := d.promoter.lookups(wordset.NormalizeText(word.Text), text)
+	if err != nil {
+		return err
+	}
+	// 查询记录写入失败或者还没有记录时也算一次
+	if count == 0 {
+		count = 1
+	}
+
+	if count >= rule.DefaultLookups {
+		err = d.addWordSet(wordset.DefaultWordSet, text, "")
+		if err != nil {
+			return err
+		}
+	}
+	if rule.Lookups > 0 && count >= rule.Lookups {
+		err = d.addWordSet(rule.WordSet, text, fmt.Sprintf("words looked up %d times or more", rule.Lookups))
+		if err != nil {
+			return err
+		}
+	}
+	if !rule.KeepRemembered && len(d.config.RestudyInterval) != 0 {
+		return d.promoter.resetRemembered(text)
+	}
+	return nil
+}
+
+// addWordSet 把单词加入单词本，每次都重新读取单词本，避免覆盖其他进程的修改
+func (d EuDictClient) addWordSet(name, text, description string) error {
+	ws, err := wordset.NewWordSet(name, d.defaultWordset.StorageDir)
+	if err != nil {
+		return err
+	}
+	err = ws.Load()
+	if err != nil {
+		return err
+	}
+	if _, exist := ws.Words[text]; exist {
+		return nil
+	}
+	if ws.Meta.Description == "" {
+		ws.Meta.Description = description
+	}
+	return ws.Append(text)
+}
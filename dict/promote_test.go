@@ -0,0 +1,116 @@
+package dict
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lai323/idict/config"
+	"github.com/lai323/idict/wordset"
+)
+
+func TestPromote(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	conf := config.Config{
+		StoragePath:     dir,
+		RestudyInterval: map[int]int{3: 0, 5: -1},
+		Promotion:       config.Promotion{DefaultLookups: 2, Lookups: 3},
+	}
+	cli, err := NewEuDictClient(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"run", "running"} {
+		err = cli.WordCache().Set(wordset.Word{
+			Text:        text,
+			PronounceUS: wordset.Pronounce{Phonetic: "/" + text + "/"},
+			Translates:  []wordset.Translate{{Mean: text}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := func(name string) map[string]int {
+		ws, err := wordset.NewWordSet(name, wordset.WordSetManage{StoragePath: dir}.WordSetDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ws.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ws.Words
+	}
+	lookup := func(text string) {
+		err, _ := cli.FetchCache(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lookup("run")
+	if _, ok := load(wordset.DefaultWordSet)["run"]; ok {
+		t.Errorf("added to default after one lookup")
+	}
+	// 变形的查询次数算在原形上
+	lookup("running")
+	if _, ok := load(wordset.DefaultWordSet)["run"]; !ok {
+		t.Errorf("not added to default after two lookups")
+	}
+	if _, ok := load(config.DefaultTroublesomeWordSet)["run"]; ok {
+		t.Errorf("added to troublesome after two lookups")
+	}
+	lookup("run")
+	if _, ok := load(config.DefaultTroublesomeWordSet)["run"]; !ok {
+		t.Errorf("not added to troublesome after three lookups")
+	}
+
+	extent, err := wordset.NewPracExtent(wordset.PracExtentFile(dir), conf.RestudyInterval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		err = extent.Remember("run")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	lookup("run")
+	extent, err = wordset.NewPracExtent(wordset.PracExtentFile(dir), conf.RestudyInterval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extent.CorrectNum("run") != 0 || extent.State("run") != wordset.StateReview {
+		t.Errorf("remembered word not reset: %d %s", extent.CorrectNum("run"), extent.State("run"))
+	}
+
+	// 其他进程修改的练习进度和查询记录也会被读取
+	for i := 0; i < 5; i++ {
+		err = extent.Remember("run")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Chtimes(wordset.PracExtentFile(dir), time.Now().Add(time.Second), time.Now().Add(time.Second))
+	lookup("run")
+	extent, err = wordset.NewPracExtent(wordset.PracExtentFile(dir), conf.RestudyInterval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extent.CorrectNum("run") != 0 {
+		t.Errorf("progress saved by another process not reloaded: %d", extent.CorrectNum("run"))
+	}
+	err = wordset.NewHistory(dir).Add("runs", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := cli.promoter.lookups("run", "run")
+	if err != nil || count != 6 {
+		t.Errorf("lookups %d %v", count, err)
+	}
+}
@@ -0,0 +1,185 @@
+package dict
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/lai323/idict/suggest"
+	"github.com/lai323/idict/wordset"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+const reverseLimit = 20
+
+// 中文释义中分隔多个意思的符号
+var senseSep = regexp.MustCompile(`[;；,，、]`)
+
+// ReverseClient 支持从中文查询英文单词
+type ReverseClient interface {
+	Reverse(string) (error, []wordset.GuessWord)
+}
+
+// IsCJK 判断输入中是否有中文，有中文时查询对应的英文单词
+func IsCJK(text string) bool {
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reverse 查询中文对应的英文单词，Label 为单词的释义
+// 在线查询的结果在前，之后是缓存中释义包含 text 的单词，无法联网时只返回缓存中的结果
+func (d EuDictClient) Reverse(text string) (error, []wordset.GuessWord) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+	remoteErr, remote := remoteReverse(text)
+	err, local := d.localReverse(text)
+	if err != nil {
+		return err, nil
+	}
+
+	// 在线结果中只有词性，优先使用缓存中的释义
+	for i, w := range remote {
+		if sense := d.sense(w.Value); sense != "" {
+			remote[i].Label = sense
+		} else {
+			remote[i].Label = strings.TrimSpace(w.Label + " " + text)
+		}
+	}
+	seen := map[string]bool{}
+	var words []wordset.GuessWord
+	for _, w := range append(remote, local...) {
+		if seen[w.Value] || len(words) >= reverseLimit {
+			continue
+		}
+		seen[w.Value] = true
+		words = append(words, w)
+	}
+	if len(words) == 0 && remoteErr != nil {
+		return fmt.Errorf("Reverse lookup %s", remoteErr.Error()), nil
+	}
+	return nil, words
+}
+
+// sense 使用缓存中的第一个翻译作为释义
+func (d EuDictClient) sense(text string) string {
+	entry, exist, err := d.wordcache.Entry(text)
+	if err != nil || !exist {
+		return ""
+	}
+	return suggest.Label(entry.Word.Translates)
+}
+
+// localReverse 在缓存中查找释义包含 text 的单词，释义中某个意思与 text 完全相同的排在前面
+func (d EuDictClient) localReverse(text string) (error, []wordset.GuessWord) {
+	type match struct {
+		word  wordset.GuessWord
+		exact bool
+		size  int
+	}
+	var matches []match
+	err := d.wordcache.Walk(func(file string, entry wordset.CacheEntry) error {
+		best := match{size: -1}
+		for _, t := range entry.Word.Translates {
+			if !strings.Contains(t.Mean, text) {
+				continue
+			}
+			exact := false
+			for _, s := range senseSep.Split(t.Mean, -1) {
+				exact = exact || strings.TrimSpace(s) == text
+			}
+			m := match{
+				word:  wordset.GuessWord{Value: entry.Word.Text, Label: strings.TrimSpace(t.Part + " " + t.Mean)},
+				exact: exact,
+				size:  len([]rune(t.Mean)),
+			}
+			if best.size < 0 || (m.exact && !best.exact) || (m.exact == best.exact && m.size < best.size) {
+				best = m
+			}
+		}
+		if best.size >= 0 {
+			matches = append(matches, best)
+		}
+		return nil
+	})
+	if err != nil {
+		return err, nil
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].exact != matches[j].exact {
+			return matches[i].exact
+		}
+		return matches[i].size < matches[j].size
+	})
+	var words []wordset.GuessWord
+	for _, m := range matches {
+		words = append(words, m.word)
+	}
+	return nil, words
+}
+
+func remoteReverse(text string) (error, []wordset.GuessWord) {
+	resp, err := euquery(text)
+	if err != nil {
+		return err, nil
+	}
+	defer resp.Body.Close()
+	r, err := charset.NewReader(resp.Body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return err, nil
+	}
+	return parseReverse(r)
+}
+
+// parseReverse 解析中文查询的页面，每个释义中的链接或英文部分为候选的单词
+func parseReverse(r io.Reader) (error, []wordset.GuessWord) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return err, nil
+	}
+	exp := htmlquery.FindOne(doc, `//div[@id="ExpFCChild"]`)
+	if exp == nil {
+		return nil, nil
+	}
+	items := htmlquery.Find(exp, `.//li`)
+	if len(items) == 0 {
+		items = []*html.Node{exp}
+	}
+
+	var words []wordset.GuessWord
+	for _, item := range items {
+		var part string
+		if i := htmlquery.FindOne(item, `./i`); i != nil {
+			part = strings.TrimSpace(htmlquery.InnerText(i))
+		}
+		var candidates []string
+		for _, a := range htmlquery.Find(item, `.//a`) {
+			candidates = append(candidates, htmlquery.InnerText(a))
+		}
+		if len(candidates) == 0 {
+			text := htmlquery.InnerText(item)
+			if part != "" {
+				text = strings.Replace(text, part, "", 1)
+			}
+			candidates = senseSep.Split(text, -1)
+		}
+		for _, c := range candidates {
+			c = wordset.NormalizeWord(c)
+			if c == "" || !wordset.ValidWord(c) {
+				continue
+			}
+			words = append(words, wordset.GuessWord{Value: c, Label: part})
+		}
+	}
+	return nil, words
+}
@@ -0,0 +1,65 @@
+package dict
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lai323/idict/config"
+	"github.com/lai323/idict/wordset"
+)
+
+func TestIsCJK(t *testing.T) {
+	if !IsCJK("苹果") || !IsCJK("a 苹果") || IsCJK("apple") || IsCJK("café") {
+		t.Errorf("IsCJK")
+	}
+}
+
+func TestParseReverse(t *testing.T) {
+	page := `<html><body><div id="ExpFCChild">
+	<ol>
+	<li><i>n.</i> <a href="/dicts/en/apple">apple</a>; <a href="/dicts/en/apple tree">apple tree</a></li>
+	<li><i>v.</i> leave, depart；苹果</li>
+	</ol></div></body></html>`
+	err, words := parseReverse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, w := range words {
+		got = append(got, w.Value+"/"+w.Label)
+	}
+	if strings.Join(got, ",") != "apple/n.,apple tree/n.,leave/v.,depart/v." {
+		t.Errorf("parseReverse %v", got)
+	}
+}
+
+func TestLocalReverse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cli, err := NewEuDictClient(config.Config{StoragePath: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for text, mean := range map[string]string{
+		"apple":      "苹果",
+		"applesauce": "苹果酱；胡说",
+		"pear":       "梨",
+	} {
+		err = cli.WordCache().Set(wordset.Word{Text: text, Translates: []wordset.Translate{{Part: "n.", Mean: mean}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err, words := cli.localReverse("苹果")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(words) != 2 || words[0].Value != "apple" || words[0].Label != "n. 苹果" || words[1].Value != "applesauce" {
+		t.Errorf("localReverse %v", words)
+	}
+}
@@ -0,0 +1,116 @@
+package dict
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/lai323/idict/config"
+	"github.com/lai323/idict/wordset"
+)
+
+// SocketPath 返回后台服务的 Unix socket，每个存储位置一个
+func SocketPath(storagePath string) string {
+	return path.Join(storagePath, "run", "idict.sock")
+}
+
+// SocketClient 通过 Unix socket 使用后台服务中的客户端，接口与 idict serve 相同
+type SocketClient struct {
+	socket string
+	http   *http.Client
+}
+
+func NewSocketClient(socket string) SocketClient {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}
+	return SocketClient{socket: socket, http: &http.Client{Transport: transport, Timeout: 30 * time.Second}}
+}
+
+// Alive 判断后台服务是否在运行
+func (c SocketClient) Alive() bool {
+	conn, err := net.DialTimeout("unix", c.socket, 100*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func (c SocketClient) get(p string, query url.Values, v interface{}) error {
+	// Host 需要是本机地址
+	resp, err := c.http.Get("http://localhost" + p + "?" + query.Encode())
+	if err != nil {
+		return fmt.Errorf("daemon %s", err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return fmt.Errorf("daemon %s %s", resp.Status, e.Error)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (c SocketClient) lookup(text string, query url.Values) (error, wordset.Word) {
+	var word wordset.Word
+	query.Set("word", text)
+	err := c.get("/lookup", query, &word)
+	return err, word
+}
+
+func (c SocketClient) Fetch(text string) (error, wordset.Word) {
+	return c.lookup(text, url.Values{"cache": {"false"}})
+}
+
+func (c SocketClient) FetchCache(text string) (error, wordset.Word) {
+	return c.lookup(text, url.Values{"record": {"true"}})
+}
+
+func (c SocketClient) Cache(text string) (error, wordset.Word) {
+	return c.lookup(text, url.Values{})
+}
+
+func (c SocketClient) Guess(text string) (error, []wordset.GuessWord) {
+	var words []wordset.GuessWord
+	err := c.get("/suggest", url.Values{"q": {text}, "limit": {fmt.Sprint(guessLimit * 2)}}, &words)
+	return err, words
+}
+
+func (c SocketClient) Reverse(text string) (error, []wordset.GuessWord) {
+	var words []wordset.GuessWord
+	err := c.get("/reverse", url.Values{"q": {text}}, &words)
+	return err, words
+}
+
+// Shutdown 停止后台服务
+func (c SocketClient) Shutdown() error {
+	resp, err := c.http.Post("http://localhost/shutdown", "", nil)
+	if err != nil {
+		return fmt.Errorf("daemon %s", err.Error())
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("daemon shutdown %s", resp.Status)
+	}
+	return nil
+}
+
+// NewClient 在后台服务运行时使用后台服务，否则在当前进程中查询
+func NewClient(config config.Config) (DictClient, error) {
+	c := NewSocketClient(SocketPath(config.StoragePath))
+	if c.Alive() {
+		return c, nil
+	}
+	return NewEuDictClient(config)
+}
@@ -0,0 +1,24 @@
+package dict
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/lai323/idict/config"
+)
+
+func TestNewClientFallback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cli, err := NewClient(config.Config{StoragePath: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cli.(EuDictClient); !ok {
+		t.Errorf("client %T without daemon", cli)
+	}
+}
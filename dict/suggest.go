@@ -0,0 +1,112 @@
+package dict
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lai323/idict/suggest"
+	"github.com/lai323/idict/wordset"
+)
+
+const guessLimit = 10
+
+// 联想词在每次输入后获取，超时时间比查询短
+var guessClient = &http.Client{Timeout: 3 * time.Second}
+
+// suggester 在第一次联想时建立本地索引，之后查询过的单词也会加入索引
+type suggester struct {
+	wordcache  wordset.WordCache
+	wordsetDir string
+
+	mu    sync.Mutex
+	built bool
+	index *suggest.Index
+}
+
+// suggest 返回本地索引中的联想词，建立索引出错时只在第一次返回错误
+func (s *suggester) suggest(text string) ([]suggest.Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var err error
+	if !s.built {
+		s.built = true
+		// 部分来源读取失败时使用已建立的部分索引
+		s.index, err = suggest.Build(s.wordcache, s.wordsetDir)
+		if err != nil {
+			err = fmt.Errorf("suggest index %s", err.Error())
+		}
+	}
+	if s.index == nil {
+		return nil, err
+	}
+	return s.index.Suggest(text, guessLimit), err
+}
+
+// add 把查询过的单词加入索引，索引还没有建立时跳过，建立时会从缓存中读取
+func (s *suggester) add(word wordset.Word) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index == nil || len(word.Translates) == 0 {
+		return
+	}
+	s.index.Add(word.Text, suggest.Label(word.Translates), suggest.ScoreCached)
+}
+
+// Guess 同时查询本地索引和在线联想词，在线的结果在前，无法联网时只有本地的结果
+func (d EuDictClient) Guess(text string) (error, []wordset.GuessWord) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+
+	var (
+		local    []suggest.Entry
+		localErr error
+	)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// 本地索引中只有英文单词
+		if d.suggester != nil && !IsCJK(text) {
+			local, localErr = d.suggester.suggest(text)
+		}
+	}()
+	err, remote := remoteGuess(text)
+	<-done
+	if err == nil {
+		err = localErr
+	}
+	return err, mergeGuess(remote, local, guessLimit)
+}
+
+func mergeGuess(remote []wordset.GuessWord, local []suggest.Entry, limit int) []wordset.GuessWord {
+	seen := map[string]bool{}
+	var guesses []wordset.GuessWord
+	for _, g := range remote {
+		key := strings.ToLower(g.Value)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		guesses = append(guesses, g)
+	}
+	// 在线的结果太多时仍然保留几个本地的结果，例如拼写错误时的纠正
+	max := limit
+	if len(local) != 0 && len(guesses) > limit-3 {
+		max = len(guesses) + 3
+	}
+	for _, e := range local {
+		if len(guesses) >= max {
+			break
+		}
+		if seen[e.Word] {
+			continue
+		}
+		seen[e.Word] = true
+		guesses = append(guesses, wordset.GuessWord{Value: e.Word, Label: e.Label})
+	}
+	return guesses
+}
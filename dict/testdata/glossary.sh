@@ -0,0 +1,31 @@
+#!/bin/sh
+# idict 的外部词典示例，在配置中设置:
+#
+#   Provider:
+#     Name: glossary
+#     Command: [/path/to/glossary.sh, "{word}"]
+#     Timeout: 5
+#
+# 参数为查询的单词，查到时把 JSON 写到标准输出，键与 wordset.Word 相同，不区分大小写，
+# 查询失败时以非 0 状态退出并把原因写到标准错误
+
+case "$1" in
+idempotent)
+	cat <<'JSON'
+{
+  "text": "idempotent",
+  "pronounceUS": {"phonetic": "/ˌaɪdəmˈpoʊtənt/"},
+  "translates": [{"part": "adj.", "mean": "幂等的"}],
+  "sentences": [{"text": "PUT requests should be idempotent.", "trans": "PUT 请求应该是幂等的。"}]
+}
+JSON
+	;;
+sharding)
+	# 没有音标的词条
+	echo '{"translates": [{"part": "n.", "mean": "分片"}]}'
+	;;
+*)
+	echo "not found: $1" >&2
+	exit 1
+	;;
+esac
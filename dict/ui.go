@@ -2,17 +2,16 @@ package dict
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lai323/idict/audio"
 	idictconfig "github.com/lai323/idict/config"
 	"github.com/lai323/idict/ui"
 	"github.com/lai323/idict/wordset"
@@ -26,6 +25,7 @@ var (
 
 type GuessMsg struct {
 	words []wordset.GuessWord
+	err   error
 }
 
 type guessModel struct {
@@ -59,17 +59,42 @@ func (m guessModel) View() string {
 	return strings.Join(wordtext, "\n")
 }
 
+// ReverseMsg 为中文查询到的英文单词
+type ReverseMsg struct {
+	text  string
+	words []wordset.GuessWord
+	err   error
+}
+
+type historyMsg struct {
+	words []wordset.GuessWord
+	err   error
+}
+
+// navWordMsg 为前进后退时查询到的单词，不会加入导航记录
+type navWordMsg struct {
+	word wordset.Word
+	err  error
+}
+
+// VoiceMsg 为发音完成，Err 为无法获取或播放的原因
 type VoiceMsg struct {
+	Err error
 }
 
 func initialDictModel(text string, config *idictconfig.Config) (DictModel, error) {
 	m := DictModel{config: config}
-	cli, err := NewEuDictClient(*config)
+	cli, err := NewClient(*config)
+	if err != nil {
+		return m, err
+	}
+	speaker, err := audio.NewSpeaker(*config)
 	if err != nil {
 		return m, err
 	}
 	m.config = config
 	m.cli = cli
+	m.speaker = speaker
 	m.text = text
 	m.textInput = textinput.NewModel()
 	m.textInput.Placeholder = "Type to input"
@@ -83,11 +108,37 @@ func initialDictModel(text string, config *idictconfig.Config) (DictModel, error
 	m.guessctx = context.Background()
 	m.guessdelay = 500
 
+	// 之前查询过的单词可以用后退键查看
+	m.history = wordset.NewHistory(config.StoragePath)
+	entries, err := m.history.Entries()
+	if err != nil {
+		return m, err
+	}
+	recent := wordset.RecentWords(entries, navLimit)
+	for i := len(recent) - 1; i >= 0; i-- {
+		m.nav = append(m.nav, recent[i])
+	}
+	m.navpos = len(m.nav)
+
+	m.annotations, err = wordset.NewAnnotations(config.StoragePath)
+	if err != nil {
+		return m, err
+	}
+
 	m.helpmode = ui.HelpModel{
 		Keyhelp: [][]string{
 			{"?", "back"},
 			{"i", "active input"},
-			{"v", "voice (if ffplay has been set)"},
+			{"v", "voice (if audio player has been set)"},
+			{"U", "US voice"},
+			{"K", "UK voice"},
+			{"s", "speak next example sentence"},
+			{"←", "back to previous word"},
+			{"→", "forward to next word"},
+			{"H", "lookup history"},
+			{"*", "star or unstar word"},
+			{"t", "edit tags, separated by comma"},
+			{"n", "edit note"},
 			{"j", "up"},
 			{"k", "down"},
 			{"u", "page up"},
@@ -99,6 +150,8 @@ func initialDictModel(text string, config *idictconfig.Config) (DictModel, error
 
 type DictModel struct {
 	cli             DictClient
+	speaker         audio.Speaker
+	sentencecursor  int
 	config          *idictconfig.Config
 	text            string
 	ready           bool
@@ -114,14 +167,46 @@ type DictModel struct {
 	guessdelay      int64
 	width           int
 	lastmodel       string
+	history         wordset.History
+	nav             []string
+	navpos          int
+	annotations     wordset.Annotations
+	// 正在编辑的注释，"tags" 或 "note"，编辑时输入框用于输入注释
+	editing string
+	// 显示在底部的错误，按键后清除
+	status string
 }
 
+const (
+	navLimit     = 100
+	historyLimit = 50
+)
+
 func (m DictModel) Init() tea.Cmd {
 	if m.text == "" {
 		return textinput.Blink
 	}
 	m.textInput.SetValue(m.text)
-	return tea.Batch(textinput.Blink, m.fetchCmd(m.text))
+	return tea.Batch(textinput.Blink, m.lookupCmd(m.text))
+}
+
+// lookupCmd 查询英文单词，输入中文时查询对应的英文单词
+func (m *DictModel) lookupCmd(text string) func() tea.Msg {
+	if IsCJK(text) {
+		return m.reverseCmd(text)
+	}
+	return m.fetchCmd(text)
+}
+
+func (m *DictModel) reverseCmd(text string) func() tea.Msg {
+	return func() tea.Msg {
+		cli, ok := m.cli.(ReverseClient)
+		if !ok {
+			return ReverseMsg{text: text, err: fmt.Errorf("reverse lookup is not supported")}
+		}
+		err, words := cli.Reverse(text)
+		return ReverseMsg{text: text, words: words, err: err}
+	}
 }
 
 func (m *DictModel) fetchCmd(text string) func() tea.Msg {
@@ -130,7 +215,65 @@ func (m *DictModel) fetchCmd(text string) func() tea.Msg {
 		if err != nil {
 			panic(fmt.Errorf("fetch translate word error: %s", err.Error()))
 		}
-		return ui.WordMsg{word}
+		return ui.WordMsg{Word: word}
+	}
+}
+
+// navCmd 后退或前进到导航记录中的单词，使用缓存查询，不会再次记录
+func (m *DictModel) navCmd(step int) tea.Cmd {
+	pos := m.navpos + step
+	if pos < 0 || pos >= len(m.nav) {
+		return nil
+	}
+	m.navpos = pos
+	text := m.nav[pos]
+	m.textInput.SetValue(text)
+	m.textInput.SetCursor(len(text))
+	return func() tea.Msg {
+		err, word := m.cli.Cache(text)
+		return navWordMsg{word: word, err: err}
+	}
+}
+
+// pushNav 记录新查询的单词，当前位置之后的记录会被丢弃
+func (m *DictModel) pushNav(text string) {
+	if m.navpos == len(m.nav) && m.navpos > 0 && m.nav[m.navpos-1] == text {
+		m.navpos--
+		return
+	}
+	if m.navpos < len(m.nav) && m.nav[m.navpos] == text {
+		return
+	}
+	if m.navpos+1 < len(m.nav) {
+		m.nav = m.nav[:m.navpos+1]
+	}
+	m.nav = append(m.nav, text)
+	if len(m.nav) > navLimit {
+		m.nav = m.nav[len(m.nav)-navLimit:]
+	}
+	m.navpos = len(m.nav) - 1
+}
+
+// historyCmd 在联想列表中列出最近查询的单词，选择后再次查询
+func (m *DictModel) historyCmd() tea.Cmd {
+	return func() tea.Msg {
+		entries, err := m.history.Entries()
+		if err != nil {
+			return historyMsg{err: err}
+		}
+		counts := wordset.HistoryCounts(entries)
+		last := map[string]int64{}
+		for _, e := range entries {
+			last[e.Word] = e.Time
+		}
+		var words []wordset.GuessWord
+		for _, w := range wordset.RecentWords(entries, historyLimit) {
+			words = append(words, wordset.GuessWord{
+				Value: w,
+				Label: fmt.Sprintf("%s  %d times", time.Unix(last[w], 0).Format("2006-01-02 15:04"), counts[w]),
+			})
+		}
+		return historyMsg{words: words}
 	}
 }
 
@@ -140,21 +283,37 @@ func (m *DictModel) helpCmd() tea.Cmd {
 	}
 }
 
-func (m *DictModel) voiceCmd() tea.Cmd {
-	voicecmd := m.config.FfplayPath
-	voicearg := m.config.FfplayArgs
-	word := m.transmodel.Word.Text
-	if !(voicecmd != "" && word != "") {
+// 播放当前单词，accent 为空时使用配置的口音
+func (m *DictModel) voiceCmd(accent string) tea.Cmd {
+	word := m.transmodel.Word
+	if accent == "" {
+		accent = m.config.VoiceAccent
+	}
+	voice := word.PronounceUS.Voice
+	if accent == "uk" {
+		voice = word.PronounceUK.Voice
+	}
+	return m.speakCmd(word.Text, voice)
+}
+
+// 依次播放例句
+func (m *DictModel) sentenceVoiceCmd() tea.Cmd {
+	sentences := m.transmodel.Word.Sentences
+	if len(sentences) == 0 {
+		return nil
+	}
+	sentence := sentences[m.sentencecursor%len(sentences)]
+	m.sentencecursor++
+	return m.speakCmd(sentence.Text, "")
+}
+
+func (m *DictModel) speakCmd(text, voice string) tea.Cmd {
+	if text == "" {
 		return nil
 	}
 
 	return func() tea.Msg {
-		text := "QYN" + base64.StdEncoding.EncodeToString([]byte(word))
-		url := `https://api.frdic.com/api/v2/speech/speakweb?langid=en&voicename=en_us_female&txt=` + text
-		voicearg = append(voicearg, url)
-		cmd := exec.Command(voicecmd, voicearg...)
-		cmd.Run()
-		return VoiceMsg{}
+		return VoiceMsg{Err: m.speaker.SpeakVoice(text, voice)}
 	}
 }
 
@@ -179,10 +338,7 @@ func (m *DictModel) guessCmd() tea.Cmd {
 				return nil
 			case <-time.After(time.Millisecond * time.Duration(m.guessdelay)):
 				err, words := m.cli.Guess(m.textInput.Value())
-				if err != nil {
-					panic(fmt.Errorf("guess word error: %s", err.Error()))
-				}
-				return GuessMsg{words: words}
+				return GuessMsg{words: words, err: err}
 			}
 		}
 	}
@@ -196,7 +352,21 @@ func (m DictModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		m.status = ""
+		if m.editing != "" {
+			return m.updateEditing(msg)
+		}
 		switch msg.String() {
+		case "*", "t", "n":
+			if !m.textInput.Focused() && m.transmodel.Word.Text != "" {
+				if msg.String() == "*" {
+					a := m.transmodel.Annotation
+					a.Star = !a.Star
+					m.saveAnnotation(a)
+					return m, nil
+				}
+				return m, m.startEditing(msg.String())
+			}
 		case "i", "backspace":
 			if !m.textInput.Focused() {
 				// 需要重置 viewport 因为联想内容和当前内容高度不同
@@ -210,11 +380,11 @@ func (m DictModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				text := m.guessmodel.words.words[m.guessmodel.cursor-1].Value
 				m.textInput.SetValue(text)
 				m.textInput.SetCursor(len(text))
-				cmds = append(cmds, m.fetchCmd(text))
+				cmds = append(cmds, m.lookupCmd(text))
 			} else {
 				text := m.textInput.Value()
 				if text != "" {
-					cmds = append(cmds, m.fetchCmd(text))
+					cmds = append(cmds, m.lookupCmd(text))
 				}
 			}
 		case "esc":
@@ -241,9 +411,33 @@ func (m DictModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if !m.textInput.Focused() {
 				cmds = append(cmds, m.helpCmd())
 			}
-		case "v":
+		case "left", "right":
 			if !m.textInput.Focused() {
-				cmd := m.voiceCmd()
+				step := -1
+				if msg.String() == "right" {
+					step = 1
+				}
+				if cmd := m.navCmd(step); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+			}
+		case "H":
+			if !m.textInput.Focused() {
+				cmds = append(cmds, m.historyCmd())
+			}
+		case "v", "U", "K", "s":
+			if !m.textInput.Focused() {
+				var cmd tea.Cmd
+				switch msg.String() {
+				case "v":
+					cmd = m.voiceCmd("")
+				case "U":
+					cmd = m.voiceCmd("us")
+				case "K":
+					cmd = m.voiceCmd("uk")
+				case "s":
+					cmd = m.sentenceVoiceCmd()
+				}
 				if cmd != nil {
 					cmds = append(cmds, cmd)
 				}
@@ -265,19 +459,66 @@ func (m DictModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.viewport.SetContent(wordwrap.String(m.viewportContent, m.viewport.Width))
 	case ui.WordMsg:
-		m.guessmodel.active = false
-		m.guessmodel.cursor = 0
-		m.transmodel.Word = msg.Word
-		m.updatetrans()
+		if msg.Word.Text != "" {
+			m.pushNav(msg.Word.Text)
+		}
+		m.showWord(msg.Word)
+	case navWordMsg:
+		// 例如离线时缓存已经被清理，保留当前显示的单词
+		if msg.err != nil {
+			m.status = "lookup: " + msg.err.Error()
+			break
+		}
+		m.showWord(msg.word)
+	case historyMsg:
+		if msg.err != nil {
+			m.status = "history: " + msg.err.Error()
+			break
+		}
+		if len(msg.words) == 0 {
+			m.lastmodel = ""
+			m.viewportContent = "No lookup history"
+			m.viewport.SetContent(m.viewportContent)
+			break
+		}
+		m.guessmodel.words = GuessMsg{words: msg.words}
+		m.guessmodel.active = true
+		m.guessmodel.cursor = 1
+		m.updateguess()
 	case GuessMsg:
+		// 例如后台服务已经退出，保留之前的联想词
+		if msg.err != nil {
+			m.status = "guess: " + msg.err.Error()
+			break
+		}
 		if m.textInput.Focused() {
 			m.guessmodel.words = msg
 			m.guessmodel.active = true
 			m.updateguess()
 		}
+	case ReverseMsg:
+		// 列出候选的单词，选择后查询并加入默认单词本
+		if msg.err != nil || len(msg.words) == 0 {
+			m.guessmodel.active = false
+			m.guessmodel.cursor = 0
+			m.lastmodel = ""
+			m.viewportContent = fmt.Sprintf("No English word found for %s", msg.text)
+			if msg.err != nil {
+				m.viewportContent += ": " + msg.err.Error()
+			}
+			m.viewport.SetContent(wordwrap.String(m.viewportContent, m.viewport.Width))
+			break
+		}
+		m.guessmodel.words = GuessMsg{words: msg.words}
+		m.guessmodel.active = true
+		m.guessmodel.cursor = 1
+		m.updateguess()
 	case ui.HelpMsg:
 		m.updatehelp()
 	case VoiceMsg:
+		if msg.Err != nil {
+			m.status = "voice: " + msg.Err.Error()
+		}
 	}
 
 	// Handle character input and blinks
@@ -292,6 +533,81 @@ func (m DictModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// startEditing 使用输入框编辑当前单词的标签或笔记
+func (m *DictModel) startEditing(field string) tea.Cmd {
+	a := m.transmodel.Annotation
+	m.editing = "note"
+	value := a.Note
+	if field == "t" {
+		m.editing = "tags"
+		value = strings.Join(a.Tags, ", ")
+	}
+	m.textInput.Prompt = m.editing + focusedPrompt
+	m.textInput.SetValue(value)
+	m.textInput.SetCursor(len(value))
+	m.textInput.Focus()
+	return textinput.Blink
+}
+
+func (m DictModel) updateEditing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "enter":
+		a := m.transmodel.Annotation
+		if m.editing == "tags" {
+			a.Tags = wordset.ParseTags(m.textInput.Value())
+		} else {
+			a.Note = strings.TrimSpace(m.textInput.Value())
+		}
+		m.stopEditing()
+		m.saveAnnotation(a)
+		return m, nil
+	case "esc":
+		m.stopEditing()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+func (m *DictModel) stopEditing() {
+	m.editing = ""
+	m.textInput.Prompt = focusedPrompt
+	m.textInput.SetValue(m.transmodel.Word.Text)
+	m.textInput.SetCursor(len(m.transmodel.Word.Text))
+	m.textInput.Blur()
+}
+
+func (m *DictModel) saveAnnotation(a wordset.Annotation) {
+	err := m.annotations.Set(m.transmodel.Word.Text, a)
+	if err != nil {
+		m.lastmodel = ""
+		m.viewportContent = fmt.Sprintf("Save annotation error: %s", err.Error())
+		m.viewport.SetContent(wordwrap.String(m.viewportContent, m.viewport.Width))
+		return
+	}
+	m.transmodel.Annotation = m.annotations.Get(m.transmodel.Word.Text)
+	m.updatetrans()
+}
+
+func (m *DictModel) showWord(word wordset.Word) {
+	m.guessmodel.active = false
+	m.guessmodel.cursor = 0
+	m.transmodel.Word = word
+	m.transmodel.Annotation = m.annotations.Get(word.Text)
+	// 旧的缓存中没有记录发音
+	if m.transmodel.Word.PronounceUS.Voice == "" {
+		m.transmodel.Word.PronounceUS.Voice = m.speaker.AccentVoice("us")
+	}
+	if m.transmodel.Word.PronounceUK.Voice == "" {
+		m.transmodel.Word.PronounceUK.Voice = m.speaker.AccentVoice("uk")
+	}
+	m.sentencecursor = 0
+	m.updatetrans()
+}
+
 func (m *DictModel) updatetrans() {
 	m.lastmodel = "trans"
 	m.viewportContent = m.transmodel.View()
@@ -336,7 +652,7 @@ func (m DictModel) View() string {
 		[]string{
 			m.textInput.View(), "\n",
 			m.viewport.View(), "\n",
-			ui.Footer(m.viewport.Width),
+			ui.StatusFooter(m.viewport.Width, m.status),
 		},
 		"",
 	)
@@ -0,0 +1,41 @@
+package dict
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lai323/idict/wordset"
+)
+
+// userDictLoader 在文件修改后重新读取自己的词典，编辑后不需要重新启动
+type userDictLoader struct {
+	storagePath string
+
+	mu      sync.Mutex
+	modTime time.Time
+	dict    wordset.UserDict
+	loaded  bool
+}
+
+func (l *userDictLoader) get() (wordset.UserDict, error) {
+	if l == nil {
+		return wordset.UserDict{}, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var modTime time.Time
+	info, err := os.Stat(wordset.UserDictFile(l.storagePath))
+	if err == nil {
+		modTime = info.ModTime()
+	}
+	if l.loaded && modTime.Equal(l.modTime) {
+		return l.dict, nil
+	}
+	d, err := wordset.NewUserDict(l.storagePath)
+	if err != nil {
+		return d, err
+	}
+	l.dict, l.modTime, l.loaded = d, modTime, true
+	return d, nil
+}
@@ -0,0 +1,285 @@
+package exporter
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// anki 2.1 之前的 collection.anki2 格式，新旧版本的 anki 都可以导入
+const ankiSchema = `
+CREATE TABLE col (
+	id integer primary key, crt integer not null, mod integer not null, scm integer not null,
+	ver integer not null, dty integer not null, usn integer not null, ls integer not null,
+	conf text not null, models text not null, decks text not null, dconf text not null, tags text not null
+);
+CREATE TABLE notes (
+	id integer primary key, guid text not null, mid integer not null, mod integer not null,
+	usn integer not null, tags text not null, flds text not null, sfld integer not null,
+	csum integer not null, flags integer not null, data text not null
+);
+CREATE TABLE cards (
+	id integer primary key, nid integer not null, did integer not null, ord integer not null,
+	mod integer not null, usn integer not null, type integer not null, queue integer not null,
+	due integer not null, ivl integer not null, factor integer not null, reps integer not null,
+	lapses integer not null, left integer not null, odue integer not null, odid integer not null,
+	flags integer not null, data text not null
+);
+CREATE TABLE revlog (
+	id integer primary key, cid integer not null, usn integer not null, ease integer not null,
+	ivl integer not null, lastIvl integer not null, factor integer not null, time integer not null,
+	type integer not null
+);
+CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null);
+CREATE INDEX ix_notes_usn on notes (usn);
+CREATE INDEX ix_cards_usn on cards (usn);
+CREATE INDEX ix_revlog_usn on revlog (usn);
+CREATE INDEX ix_cards_nid on cards (nid);
+CREATE INDEX ix_cards_sched on cards (did, queue, due);
+CREATE INDEX ix_revlog_cid on revlog (cid);
+CREATE INDEX ix_notes_csum on notes (csum);
+`
+
+var ankiFields = []string{"Word", "Phonetic", "Meaning", "Example", "ExampleTrans", "Note", "Audio"}
+
+const (
+	ankiFront = `<div class="word">{{Word}}</div>
+<div class="phonetic">{{Phonetic}}</div>
+{{Audio}}`
+	ankiBack = `{{FrontSide}}
+<hr id="answer">
+<div class="meaning">{{Meaning}}</div>
+{{#Example}}<div class="example">{{Example}}<br><span class="trans">{{ExampleTrans}}</span></div>{{/Example}}
+{{#Note}}<div class="note">{{Note}}</div>{{/Note}}`
+	ankiCSS = `.card { font-family: arial; font-size: 20px; text-align: center; color: black; background-color: white; }
+.word { font-size: 32px; font-weight: bold; }
+.phonetic { color: #888; }
+.meaning { text-align: left; display: inline-block; }
+.example { margin-top: 12px; font-style: italic; }
+.trans { color: #888; font-style: normal; }
+.note { margin-top: 12px; color: #3a7; }`
+)
+
+// ankiID 由名称生成稳定的 id，重复导出同一个单词本时 anki 会更新而不是新建
+func ankiID(name string) int64 {
+	sum := sha1.Sum([]byte(name))
+	return int64(binary.BigEndian.Uint64(sum[:8]) >> 12)
+}
+
+func ankiChecksum(field string) int64 {
+	sum := sha1.Sum([]byte(field))
+	n, _ := strconv.ParseInt(hex.EncodeToString(sum[:4]), 16, 64)
+	return n
+}
+
+func ankiFieldsOf(e Entry, sound string) []string {
+	meaning := strings.Split(e.Meaning("\n"), "\n")
+	for i := range meaning {
+		meaning[i] = html.EscapeString(meaning[i])
+	}
+	fields := []string{
+		html.EscapeString(e.Word),
+		html.EscapeString(e.Phonetic()),
+		strings.Join(meaning, "<br>"),
+		html.EscapeString(strings.TrimSpace(e.Sentence.Text)),
+		html.EscapeString(strings.TrimSpace(e.Sentence.Trans)),
+		html.EscapeString(e.Note),
+		"",
+	}
+	if sound != "" {
+		fields[len(fields)-1] = "[sound:" + sound + "]"
+	}
+	return fields
+}
+
+func ankiCollection(deck string, now time.Time) (models, decks, dconf, conf string, mid, did int64) {
+	mid = ankiID("idict model")
+	did = ankiID("idict deck " + deck)
+	mod := now.Unix()
+
+	var flds []map[string]interface{}
+	for i, name := range ankiFields {
+		flds = append(flds, map[string]interface{}{
+			"name": name, "ord": i, "sticky": false, "rtl": false, "font": "Arial", "size": 20, "media": []string{},
+		})
+	}
+	model := map[string]interface{}{
+		"id": mid, "name": "idict", "type": 0, "mod": mod, "usn": -1, "sortf": 0, "did": did,
+		"tmpls": []map[string]interface{}{{
+			"name": "Card 1", "ord": 0, "qfmt": ankiFront, "afmt": ankiBack,
+			"bqfmt": "", "bafmt": "", "did": nil, "bfont": "", "bsize": 0,
+		}},
+		"flds": flds, "css": ankiCSS,
+		"latexPre":  "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage[utf8]{inputenc}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\setlength{\\parindent}{0in}\n\\begin{document}\n",
+		"latexPost": "\\end{document}", "latexsvg": false,
+		"req": []interface{}{[]interface{}{0, "any", []int{0}}}, "tags": []string{}, "vers": []string{},
+	}
+	newDeck := func(id int64, name string) map[string]interface{} {
+		return map[string]interface{}{
+			"id": id, "name": name, "desc": "", "mod": mod, "usn": -1, "collapsed": false, "conf": 1,
+			"dyn": 0, "extendNew": 10, "extendRev": 50,
+			"newToday": []int{0, 0}, "revToday": []int{0, 0}, "lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+		}
+	}
+	deckConf := map[string]interface{}{
+		"id": 1, "name": "Default", "mod": 0, "usn": 0, "maxTaken": 60, "autoplay": true, "timer": 0, "replayq": true,
+		"new": map[string]interface{}{
+			"bury": true, "delays": []int{1, 10}, "initialFactor": 2500, "ints": []int{1, 4, 7},
+			"order": 1, "perDay": 20, "separate": true,
+		},
+		"lapse": map[string]interface{}{"delays": []int{10}, "leechAction": 0, "leechFails": 8, "minInt": 1, "mult": 0},
+		"rev": map[string]interface{}{
+			"bury": true, "ease4": 1.3, "fuzz": 0.05, "ivlFct": 1, "maxIvl": 36500, "minSpace": 1, "perDay": 100,
+		},
+	}
+	collConf := map[string]interface{}{
+		"activeDecks": []int64{did}, "curDeck": did, "newSpread": 0, "collapseTime": 1200, "timeLim": 0,
+		"estTimes": true, "dueCounts": true, "curModel": mid, "nextPos": 1, "sortType": "noteFld",
+		"sortBackwards": false, "addToCur": true,
+	}
+
+	marshal := func(v interface{}) string {
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+	models = marshal(map[string]interface{}{strconv.FormatInt(mid, 10): model})
+	decks = marshal(map[string]interface{}{"1": newDeck(1, "Default"), strconv.FormatInt(did, 10): newDeck(did, deck)})
+	dconf = marshal(map[string]interface{}{"1": deckConf})
+	conf = marshal(collConf)
+	return
+}
+
+// WriteApkg 把 entries 写为 anki 卡组文件，deck 为卡组名称，有音频的单词会把音频打包进卡组
+func WriteApkg(file, deck string, entries []Entry) error {
+	tmp, err := ioutil.TempFile("", "idict-apkg-")
+	if err != nil {
+		return err
+	}
+	dbfile := tmp.Name()
+	tmp.Close()
+	defer os.Remove(dbfile)
+
+	media, err := writeAnkiCollection(dbfile, deck, entries)
+	if err != nil {
+		return fmt.Errorf("write anki collection %s", err.Error())
+	}
+
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(out)
+	err = addZipFile(zw, "collection.anki2", dbfile)
+	// 音频在 zip 中以序号命名，media 文件记录序号和文件名的对应关系
+	mediaMap := map[string]string{}
+	for i, m := range media {
+		if err != nil {
+			break
+		}
+		name := strconv.Itoa(i)
+		mediaMap[name] = filepath.Base(m)
+		err = addZipFile(zw, name, m)
+	}
+	if err == nil {
+		var w io.Writer
+		w, err = zw.Create("media")
+		if err == nil {
+			err = json.NewEncoder(w).Encode(mediaMap)
+		}
+	}
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file)
+		return fmt.Errorf("write apkg %s %s", file, err.Error())
+	}
+	return nil
+}
+
+func writeAnkiCollection(dbfile, deck string, entries []Entry) ([]string, error) {
+	// 不以 file: 开头时驱动会把 ? 之后的部分作为参数
+	db, err := sql.Open("sqlite", (&url.URL{Scheme: "file", Path: dbfile}).String())
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(ankiSchema)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	models, decks, dconf, conf, mid, did := ankiCollection(deck, now)
+	_, err = tx.Exec(`INSERT INTO col VALUES (1, ?, ?, ?, 11, 0, 0, 0, ?, ?, ?, ?, '{}')`,
+		now.Unix(), now.UnixNano()/1e6, now.UnixNano()/1e6, conf, models, decks, dconf)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		media []string
+		seen  = map[string]bool{}
+	)
+	for i, e := range entries {
+		sound := ""
+		if e.Audio != "" {
+			sound = filepath.Base(e.Audio)
+			if !seen[sound] {
+				seen[sound] = true
+				media = append(media, e.Audio)
+			}
+		}
+		fields := ankiFieldsOf(e, sound)
+		nid := ankiID("idict note " + deck + "\x1f" + e.Word)
+		_, err = tx.Exec(`INSERT INTO notes VALUES (?, ?, ?, ?, -1, 'idict', ?, ?, ?, 0, '')`,
+			nid, strconv.FormatInt(nid, 36), mid, now.Unix(), strings.Join(fields, "\x1f"), fields[0], ankiChecksum(fields[0]))
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`INSERT INTO cards VALUES (?, ?, ?, 0, ?, -1, 0, 0, ?, 0, 0, 0, 0, 0, 0, 0, 0, '')`,
+			ankiID("idict card "+deck+"\x1f"+e.Word), nid, did, now.Unix(), i+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return media, tx.Commit()
+}
+
+func addZipFile(zw *zip.Writer, name, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,139 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/lai323/idict/audio"
+	idictconfig "github.com/lai323/idict/config"
+	"github.com/lai323/idict/dict"
+	"github.com/lai323/idict/wordset"
+	"github.com/spf13/cobra"
+)
+
+type Options struct {
+	Format *string
+	// 导出时包括单词的音频，没有缓存的音频会先下载
+	Audio *bool
+	// 联网获取没有缓存的单词，默认只导出缓存中的内容
+	Fetch *bool
+}
+
+func Export(config *idictconfig.Config, options Options) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if config.StoragePath == "" {
+			return errors.New("StoragePath empty")
+		}
+		m := wordset.WordSetManage{StoragePath: config.StoragePath, RestudyInterval: config.RestudyInterval}
+		name := args[0]
+		format := *options.Format
+		if format == "" {
+			format = FormatPlain
+		}
+		if !contains(Formats, format) {
+			return fmt.Errorf("unknown export format %s", format)
+		}
+
+		file := ""
+		if len(args) == 2 {
+			file = args[1]
+		} else if format == FormatAnki {
+			file = name + ".apkg"
+		}
+		if format == FormatPlain {
+			return writeFile(file, func(w io.Writer) error {
+				return m.Export(name, w)
+			})
+		}
+
+		ws, err := m.Load(name)
+		if err != nil {
+			return err
+		}
+
+		cli, err := dict.NewEuDictClient(*config)
+		if err != nil {
+			return err
+		}
+		userdict, err := wordset.NewUserDict(config.StoragePath)
+		if err != nil {
+			return err
+		}
+		e := Exporter{Cache: cli.WordCache(), UserDict: userdict}
+		if *options.Fetch {
+			e.Client = cli
+		}
+		if *options.Audio {
+			speaker, err := audio.NewSpeaker(*config)
+			if err != nil {
+				return err
+			}
+			e.Audio = func(text string) (string, error) {
+				return speaker.Fetch(text, "")
+			}
+		}
+		entries, failed := e.Entries(ws)
+		var texts, missing []string
+		for text, err := range failed {
+			if err == ErrNotCached {
+				missing = append(missing, text)
+			} else {
+				texts = append(texts, text)
+			}
+		}
+		sort.Strings(texts)
+		sort.Strings(missing)
+		for _, text := range texts {
+			fmt.Fprintf(os.Stderr, "incomplete: %s: %s\n", text, failed[text])
+		}
+		for _, text := range missing {
+			fmt.Fprintf(os.Stderr, "not cached: %s\n", text)
+		}
+		if len(missing) != 0 {
+			fmt.Fprintf(os.Stderr, "%d words not cached, use --fetch to look them up online\n", len(missing))
+		}
+
+		switch format {
+		case FormatAnki:
+			err = WriteApkg(file, name, entries)
+			if err == nil {
+				fmt.Printf("exported %d words to %s\n", len(entries), file)
+			}
+			return err
+		case FormatCSV:
+			return writeFile(file, func(w io.Writer) error { return WriteCSV(w, entries) })
+		case FormatMD:
+			return writeFile(file, func(w io.Writer) error { return WriteMarkdown(w, name, entries) })
+		default:
+			return writeFile(file, func(w io.Writer) error { return WriteJSON(w, entries) })
+		}
+	}
+}
+
+// writeFile 把内容写入 file，file 为空时写到标准输出
+func writeFile(file string, write func(io.Writer) error) error {
+	if file == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func contains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
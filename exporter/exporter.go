@@ -0,0 +1,120 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lai323/idict/dict"
+	"github.com/lai323/idict/wordset"
+)
+
+const (
+	FormatPlain = "plain"
+	FormatAnki  = "anki"
+	FormatCSV   = "csv"
+	FormatMD    = "md"
+	FormatJSON  = "json"
+)
+
+var Formats = []string{FormatPlain, FormatAnki, FormatCSV, FormatMD, FormatJSON}
+
+// ErrNotCached 为离线导出时没有缓存的单词
+var ErrNotCached = errors.New("not cached")
+
+// Entry 为导出的一个单词，包括缓存中的翻译和单词本中的附加信息
+type Entry struct {
+	Word       string
+	PhoneticUS string
+	PhoneticUK string
+	Translates []wordset.Translate
+	Sentence   wordset.Sentence
+	Note       string
+	// 本地音频文件，没有导出音频时为空
+	Audio string
+}
+
+func (e Entry) Phonetic() string {
+	var p []string
+	if e.PhoneticUS != "" {
+		p = append(p, "US "+e.PhoneticUS)
+	}
+	if e.PhoneticUK != "" {
+		p = append(p, "UK "+e.PhoneticUK)
+	}
+	return strings.Join(p, "  ")
+}
+
+// Meaning 以 sep 连接所有翻译
+func (e Entry) Meaning(sep string) string {
+	var means []string
+	for _, t := range e.Translates {
+		means = append(means, strings.TrimSpace(t.Part+" "+t.Mean))
+	}
+	return strings.Join(means, sep)
+}
+
+type Exporter struct {
+	// 离线导出时读取的缓存和自己的词典
+	Cache    wordset.WordCache
+	UserDict wordset.UserDict
+	// 不为 nil 时用来获取没有缓存的单词，需要网络
+	Client dict.DictClient
+	// 返回单词的音频文件，为 nil 时不导出音频
+	Audio func(text string) (string, error)
+}
+
+// Entries 返回单词本中所有单词，获取翻译或音频失败的单词只包含单词本中的信息，错误记录在 failed 中
+func (e Exporter) Entries(ws wordset.WordSet) (entries []Entry, failed map[string]error) {
+	failed = map[string]error{}
+	for _, text := range ws.SortedWords() {
+		err, word := e.word(text)
+		if err != nil {
+			failed[text] = err
+			word = wordset.Word{}
+		}
+		word.Text = text
+		word = ws.MergeDetail(word)
+
+		entry := Entry{
+			Word:       text,
+			PhoneticUS: word.PronounceUS.Phonetic,
+			PhoneticUK: word.PronounceUK.Phonetic,
+			Translates: word.Translates,
+			Note:       ws.Note(text),
+		}
+		if len(word.Sentences) != 0 {
+			entry.Sentence = word.Sentences[0]
+		}
+		if e.Audio != nil && err == nil {
+			entry.Audio, err = e.Audio(text)
+			if err != nil {
+				failed[text] = fmt.Errorf("audio %s", err.Error())
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, failed
+}
+
+// word 返回单词的翻译，没有 Client 时只读取缓存和自己的词典，过期的缓存也会使用
+func (e Exporter) word(text string) (error, wordset.Word) {
+	if e.Client != nil {
+		return e.Client.Cache(text)
+	}
+	entry, ok := e.UserDict.Get(text)
+	if ok && entry.Complete() {
+		return nil, entry.Merge(wordset.Word{Text: wordset.NormalizeWord(text)})
+	}
+	word, exist, err := e.Cache.Get(text)
+	if err != nil {
+		return err, word
+	}
+	if !exist {
+		if ok {
+			return nil, entry.Merge(wordset.Word{Text: wordset.NormalizeWord(text)})
+		}
+		return ErrNotCached, word
+	}
+	return nil, e.UserDict.Merge(word)
+}
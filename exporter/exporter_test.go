@@ -0,0 +1,209 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lai323/idict/importer"
+	"github.com/lai323/idict/wordset"
+)
+
+type fakeClient struct {
+	words map[string]wordset.Word
+}
+
+func (c fakeClient) Fetch(text string) (error, wordset.Word)        { return c.Cache(text) }
+func (c fakeClient) Guess(text string) (error, []wordset.GuessWord) { return nil, nil }
+func (c fakeClient) FetchCache(text string) (error, wordset.Word) {
+	return c.Cache(text)
+}
+func (c fakeClient) Cache(text string) (error, wordset.Word) {
+	word, ok := c.words[text]
+	if !ok {
+		return errors.New("not found"), wordset.Word{}
+	}
+	return nil, word
+}
+
+func testEntries(t *testing.T, dir string) []Entry {
+	ws := wordset.WordSet{
+		Name:  "test",
+		Words: map[string]int{"guess": 0, "take off": 0, "uncached": 0},
+		Details: map[string]*wordset.WordDetail{
+			"take off": {Note: "phrase"},
+		},
+	}
+	cli := fakeClient{words: map[string]wordset.Word{
+		"guess": {
+			Text:        "guess",
+			PronounceUS: wordset.Pronounce{Phonetic: "/ɡes/"},
+			Translates:  []wordset.Translate{{Part: "v.", Mean: "猜测"}, {Part: "n.", Mean: "猜想"}},
+			Sentences:   []wordset.Sentence{{Text: "Guess what?", Trans: "你猜怎么着？"}, {Text: "second"}},
+		},
+		"take off": {Text: "take off", Translates: []wordset.Translate{{Mean: "起飞"}}},
+	}}
+	audiofile := filepath.Join(dir, "guess.mp3")
+	err := ioutil.WriteFile(audiofile, []byte("mp3"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := Exporter{Client: cli, Audio: func(text string) (string, error) {
+		if text == "guess" {
+			return audiofile, nil
+		}
+		return "", nil
+	}}
+	entries, failed := e.Entries(ws)
+	if len(failed) != 1 || failed["uncached"] == nil {
+		t.Errorf("failed: %v", failed)
+	}
+	if len(entries) != 3 || entries[0].Word != "guess" || entries[0].Sentence.Text != "Guess what?" ||
+		entries[0].Audio != audiofile || entries[1].Note != "phrase" {
+		t.Fatalf("Entries: %+v", entries)
+	}
+	return entries
+}
+
+func TestEntriesOffline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache, err := wordset.NewWordCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cache.Set(wordset.Word{Text: "guess", PronounceUS: wordset.Pronounce{Phonetic: "/ɡes/"}, Translates: []wordset.Translate{{Mean: "猜测"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(wordset.UserDictFile(dir), []byte("take off:\n  replace: true\n  translates:\n  - mean: 起飞\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userdict, err := wordset.NewUserDict(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws := wordset.WordSet{Name: "test", Words: map[string]int{"guess": 0, "take off": 0, "uncached": 0}}
+	audio := 0
+	e := Exporter{Cache: cache, UserDict: userdict, Audio: func(text string) (string, error) {
+		audio++
+		return "", nil
+	}}
+	entries, failed := e.Entries(ws)
+	if len(failed) != 1 || failed["uncached"] != ErrNotCached {
+		t.Errorf("failed: %v", failed)
+	}
+	if len(entries) != 3 || entries[0].PhoneticUS != "/ɡes/" || entries[1].Meaning(",") != "起飞" || entries[2].Word != "uncached" {
+		t.Errorf("Entries: %+v", entries)
+	}
+	// 离线导出不会写入缓存
+	if _, exist, err := cache.Get("uncached"); err != nil || exist {
+		t.Errorf("uncached cached %v %v", exist, err)
+	}
+	if audio != 2 {
+		t.Errorf("audio %d", audio)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	entries := testEntries(t, dir)
+
+	var b bytes.Buffer
+	err = WriteCSV(&b, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&b).ReadAll()
+	if err != nil || len(rows) != 4 || rows[1][0] != "guess" || rows[1][3] != "v. 猜测\nn. 猜想" {
+		t.Errorf("csv: %q %v", rows, err)
+	}
+
+	b.Reset()
+	err = WriteMarkdown(&b, "test", entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"# test\n", "## guess\n", "US /ɡes/", "- *v.* 猜测", "> Guess what?", "## take off\n", "Note: phrase"} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("markdown missing %q:\n%s", s, b.String())
+		}
+	}
+
+	b.Reset()
+	err = WriteJSON(&b, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []Entry
+	err = json.Unmarshal(b.Bytes(), &decoded)
+	if err != nil || len(decoded) != 3 || decoded[1].Word != "take off" {
+		t.Errorf("json: %+v %v", decoded, err)
+	}
+}
+
+func TestWriteApkg(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	entries := testEntries(t, dir)
+
+	file := filepath.Join(dir, "test.apkg")
+	err = WriteApkg(file, "test", entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, invalid, err := importer.ReadApkg(file, importer.Columns{Word: "1", Trans: "3"})
+	if err != nil || len(invalid) != 0 || len(records) != 3 {
+		t.Fatalf("ReadApkg: %+v %v %v", records, invalid, err)
+	}
+	// note id 由单词生成，不保证顺序
+	byText := map[string]wordset.Record{}
+	for _, r := range records {
+		byText[r.Text] = r
+	}
+	if len(byText["guess"].Translates) != 2 || len(byText["take off"].Translates) != 1 {
+		t.Errorf("ReadApkg records: %+v", records)
+	}
+
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	if files["0"] == nil || files["media"] == nil {
+		t.Fatalf("apkg missing media: %v", files)
+	}
+	r, err := files["media"].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var media map[string]string
+	err = json.NewDecoder(r).Decode(&media)
+	if err != nil || media["0"] != "guess.mp3" {
+		t.Errorf("media: %v %v", media, err)
+	}
+}
@@ -0,0 +1,70 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func WriteCSV(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"word", "phonetic_us", "phonetic_uk", "meaning", "example", "example_trans", "note", "audio"})
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		err = cw.Write([]string{
+			e.Word, e.PhoneticUS, e.PhoneticUK, e.Meaning("\n"),
+			e.Sentence.Text, e.Sentence.Trans, e.Note, e.Audio,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func WriteJSON(w io.Writer, entries []Entry) error {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(entries)
+}
+
+func WriteMarkdown(w io.Writer, title string, entries []Entry) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", title)
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\n## %s\n\n", e.Word)
+		if p := e.Phonetic(); p != "" {
+			fmt.Fprintf(&b, "%s\n\n", p)
+		}
+		for _, t := range e.Translates {
+			if t.Part != "" {
+				fmt.Fprintf(&b, "- *%s* %s\n", t.Part, t.Mean)
+				continue
+			}
+			fmt.Fprintf(&b, "- %s\n", t.Mean)
+		}
+		if e.Sentence.Text != "" {
+			fmt.Fprintf(&b, "\n> %s\n", strings.TrimSpace(e.Sentence.Text))
+			if e.Sentence.Trans != "" {
+				fmt.Fprintf(&b, ">\n> %s\n", strings.TrimSpace(e.Sentence.Trans))
+			}
+		}
+		if e.Note != "" {
+			fmt.Fprintf(&b, "\nNote: %s\n", e.Note)
+		}
+		if e.Audio != "" {
+			fmt.Fprintf(&b, "\n[audio](%s)\n", e.Audio)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
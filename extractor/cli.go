@@ -0,0 +1,155 @@
+package extractor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	idictconfig "github.com/lai323/idict/config"
+	"github.com/lai323/idict/lemma"
+	"github.com/lai323/idict/wordset"
+	"github.com/spf13/cobra"
+)
+
+type Options struct {
+	Name      *string
+	Format    *string
+	Known     *[]string
+	Limit     *int
+	MinCount  *int
+	MinLength *int
+	DryRun    *bool
+}
+
+// KnownWordsFile 为默认的已认识单词列表，每行一个单词
+func KnownWordsFile(storagePath string) string {
+	return path.Join(storagePath, "known_words.txt")
+}
+
+// ReadKnownWords 读取已认识的单词列表，忽略空行和 # 开头的注释，单词转换为原形
+func ReadKnownWords(file string, known map[string]bool) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		word := wordset.NormalizeWord(line)
+		known[word] = true
+		known[lemma.Lemma(word)] = true
+	}
+	return scanner.Err()
+}
+
+func Extract(config *idictconfig.Config, options Options) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if config.StoragePath == "" {
+			return errors.New("StoragePath empty")
+		}
+		file := args[0]
+		format := *options.Format
+		if format == "" {
+			format = Detect(file)
+		}
+		if !contains(Formats, format) {
+			return fmt.Errorf("unknown text format %s", format)
+		}
+		name := *options.Name
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		}
+
+		m := wordset.WordSetManage{StoragePath: config.StoragePath, RestudyInterval: config.RestudyInterval}
+		ws, err := wordset.NewWordSet(name, m.WordSetDir())
+		if err != nil {
+			return err
+		}
+		exist, err := ws.Exist()
+		if err != nil {
+			return err
+		}
+		if exist && !*options.DryRun {
+			return fmt.Errorf("WordSet %s already exist, use --name to choose another name", name)
+		}
+
+		known := map[string]bool{}
+		files := append([]string{}, config.KnownWords...)
+		if len(files) == 0 {
+			if _, err := os.Stat(KnownWordsFile(config.StoragePath)); err == nil {
+				files = append(files, KnownWordsFile(config.StoragePath))
+			}
+		}
+		files = append(files, *options.Known...)
+		for _, f := range files {
+			err = ReadKnownWords(f, known)
+			if err != nil {
+				return fmt.Errorf("read known words %s", err.Error())
+			}
+		}
+		extent, err := wordset.NewPracExtent(wordset.PracExtentFile(config.StoragePath), config.RestudyInterval)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		text, err := Text(f, format)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("read %s %s", file, err.Error())
+		}
+
+		e := Extractor{
+			MinLength: *options.MinLength,
+			Known: func(w string) bool {
+				return known[w] || extent.Remembered(w)
+			},
+		}
+		var records []wordset.Record
+		for _, w := range e.Extract(text) {
+			if w.Count < *options.MinCount {
+				continue
+			}
+			if *options.Limit > 0 && len(records) >= *options.Limit {
+				break
+			}
+			if *options.DryRun {
+				fmt.Printf("%4d %s\n", w.Count, w.Text)
+			}
+			records = append(records, wordset.Record{
+				Text: w.Text,
+				WordDetail: wordset.WordDetail{
+					Sentences: []wordset.Sentence{{Text: w.Sentence}},
+				},
+			})
+		}
+		if *options.DryRun {
+			return nil
+		}
+		if len(records) == 0 {
+			fmt.Printf("no unknown words in %s\n", file)
+			return nil
+		}
+		return m.ImportRecords(name, file, records, nil)
+	}
+}
+
+func contains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package extractor
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/lai323/idict/lemma"
+)
+
+// Word 为从文档中提取的一个单词
+type Word struct {
+	// 单词的原形
+	Text  string
+	Count int
+	// 单词第一次出现的句子，优先使用包含原形的句子
+	Sentence string
+}
+
+type Extractor struct {
+	// 已经认识的单词，为原形，不会被提取
+	Known func(string) bool
+	// 单词的最短长度
+	MinLength int
+}
+
+var tokenRegexp = regexp.MustCompile(`[A-Za-z]+(?:['’][A-Za-z]+)*(?:-[A-Za-z]+)*`)
+
+type token struct {
+	text     string
+	sentence int
+	// 是否在句中以大写开头
+	capital bool
+	// 是否为句子的第一个单词
+	first bool
+}
+
+// Extract 从正文中提取生词，按出现次数从多到少排序，次数相同时先出现的在前
+func (e Extractor) Extract(text string) []Word {
+	sentences := Sentences(text)
+
+	var tokens []token
+	vocab := map[string]bool{}
+	lower := map[string]bool{}
+	for i, s := range sentences {
+		for j, t := range tokenRegexp.FindAllString(s, -1) {
+			t = strings.Replace(t, "’", "'", -1)
+			t = trimContraction(t)
+			if t == "" {
+				continue
+			}
+			word := strings.ToLower(t)
+			// 全部大写的一般为缩写，例如 API HTTP
+			if len(t) > 1 && strings.ToUpper(t) == t {
+				continue
+			}
+			tokens = append(tokens, token{
+				text:     word,
+				sentence: i,
+				capital:  unicode.IsUpper([]rune(t)[0]),
+				first:    j == 0,
+			})
+			vocab[word] = true
+			if !unicode.IsUpper([]rune(t)[0]) {
+				lower[word] = true
+			}
+		}
+	}
+
+	// 文档中出现的单词也用于选择原形，例如同时出现 makes 和 make，提取的结果由用户确认，没有已知的原形时也还原
+	lemmatizer := lemma.Lemmatizer{Known: func(w string) bool {
+		return vocab[w] || (e.Known != nil && e.Known(w))
+	}, Guess: true}
+
+	words := map[string]*Word{}
+	var order []string
+	names := map[string]bool{}
+	for _, t := range tokens {
+		// 只以大写出现在句中的一般为人名地名
+		if t.capital && !t.first && !lower[t.text] {
+			names[t.text] = true
+		}
+	}
+	for _, t := range tokens {
+		if names[t.text] || len(t.text) < e.MinLength || stopwords[t.text] {
+			continue
+		}
+		w := lemmatizer.Lemma(t.text)
+		if len(w) < e.MinLength || stopwords[w] || (e.Known != nil && (e.Known(w) || e.Known(t.text))) {
+			continue
+		}
+
+		word, ok := words[w]
+		if !ok {
+			word = &Word{Text: w, Sentence: sentences[t.sentence]}
+			words[w] = word
+			order = append(order, w)
+		}
+		word.Count++
+		if t.text == w && !containsWord(word.Sentence, w) {
+			word.Sentence = sentences[t.sentence]
+		}
+	}
+
+	result := make([]Word, 0, len(order))
+	for _, w := range order {
+		result = append(result, *words[w])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Count > result[j].Count
+	})
+	return result
+}
+
+// trimContraction 去掉所有格和缩写，否定的缩写为虚词，直接忽略
+func trimContraction(t string) string {
+	lower := strings.ToLower(t)
+	if strings.HasSuffix(lower, "n't") {
+		return ""
+	}
+	for _, suffix := range []string{"'s", "'ll", "'ve", "'re", "'d", "'m"} {
+		if strings.HasSuffix(lower, suffix) {
+			return t[:len(t)-len(suffix)]
+		}
+	}
+	if strings.Contains(t, "'") {
+		return ""
+	}
+	return t
+}
+
+func containsWord(sentence, word string) bool {
+	return regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(word) + `\b`).MatchString(sentence)
+}
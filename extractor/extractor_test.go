@@ -0,0 +1,81 @@
+package extractor
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSentences(t *testing.T) {
+	got := Sentences("Hello world. Mr. Smith said \"hi!\" Then\nleft, e.g. quickly?\n\nNew paragraph")
+	want := []string{"Hello world.", "Mr. Smith said \"hi!\"", "Then left, e.g. quickly?", "New paragraph"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sentences: %q", got)
+	}
+}
+
+func readText(t *testing.T, file string) string {
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	text, err := Text(f, Detect(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return text
+}
+
+func TestText(t *testing.T) {
+	md := readText(t, "testdata/article.md")
+	for _, s := range []string{"retry()", "https://", "**", "# "} {
+		if strings.Contains(md, s) {
+			t.Errorf("markdown text contains %q:\n%s", s, md)
+		}
+	}
+	html := readText(t, "testdata/page.html")
+	for _, s := range []string{"javascript", "code block", "color"} {
+		if strings.Contains(html, s) {
+			t.Errorf("html text contains %q:\n%s", s, html)
+		}
+	}
+	srt := readText(t, "testdata/movie.srt")
+	sentences := Sentences(srt)
+	if len(sentences) != 2 || sentences[0] != "We were running through the forest." {
+		t.Errorf("srt sentences: %q", sentences)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	e := Extractor{
+		MinLength: 3,
+		Known:     func(w string) bool { return w == "client" },
+	}
+	words := e.Extract(readText(t, "testdata/article.md"))
+	byText := map[string]Word{}
+	for _, w := range words {
+		byText[w.Text] = w
+	}
+	if len(words) == 0 || words[0].Count != 3 {
+		t.Fatalf("Extract: %+v", words)
+	}
+	for _, skip := range []string{"client", "alice", "the", "retries", "keys", "is"} {
+		if _, ok := byText[skip]; ok {
+			t.Errorf("Extract should skip %q", skip)
+		}
+	}
+	if byText["key"].Count != 2 || byText["retry"].Count != 3 {
+		t.Errorf("Extract counts: %+v", words)
+	}
+	if byText["operation"].Sentence != "An idempotent operation can be retried safely." {
+		t.Errorf("Extract sentence: %q", byText["operation"].Sentence)
+	}
+
+	// 优先使用包含原形的句子
+	words = Extractor{MinLength: 3}.Extract(readText(t, "testdata/movie.srt"))
+	if len(words) == 0 || words[0].Text != "run" || words[0].Count != 2 || words[0].Sentence != "Did you run?" {
+		t.Errorf("Extract srt: %+v", words)
+	}
+}
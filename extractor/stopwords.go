@@ -0,0 +1,29 @@
+package extractor
+
+// 常见的虚词，不作为生词
+var stopwords = map[string]bool{}
+
+func init() {
+	for _, w := range []string{
+		"a", "an", "the", "and", "or", "but", "nor", "so", "yet", "if", "then", "else", "than", "as",
+		"of", "in", "on", "at", "to", "for", "from", "by", "with", "about", "into", "onto", "over",
+		"under", "up", "down", "out", "off", "through", "between", "among", "after", "before",
+		"during", "without", "within", "upon", "against", "along", "around", "behind", "below",
+		"above", "beside", "beyond", "near", "since", "until", "till", "toward", "towards", "via",
+		"i", "me", "my", "mine", "myself", "you", "your", "yours", "yourself", "yourselves",
+		"he", "him", "his", "himself", "she", "her", "hers", "herself", "it", "its", "itself",
+		"we", "us", "our", "ours", "ourselves", "they", "them", "their", "theirs", "themselves",
+		"this", "that", "these", "those", "who", "whom", "whose", "which", "what", "where", "when",
+		"why", "how", "whether", "while", "there", "here", "all", "any", "both", "each", "either",
+		"neither", "every", "some", "such", "no", "not", "only", "own", "same", "other", "another",
+		"very", "too", "also", "just", "even", "still", "again", "ever", "never", "now", "once",
+		"be", "have", "do", "will", "would", "shall", "should", "can", "could", "may", "might",
+		"must", "ought", "let", "get", "go", "make", "say", "see", "know", "take", "come", "give",
+		"one", "two", "three", "first", "many", "much", "more", "most", "few", "little", "less",
+		"well", "yes", "okay", "ok", "oh", "hey", "thing", "way", "because", "although", "though",
+		"however", "therefore", "thus", "yeah", "whatever", "anything", "something", "nothing",
+		"everything", "someone", "anyone", "everyone", "nobody", "somebody", "anybody", "everybody",
+	} {
+		stopwords[w] = true
+	}
+}
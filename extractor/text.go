@@ -0,0 +1,237 @@
+package extractor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	FormatPlain    = "plain"
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+	FormatSRT      = "srt"
+)
+
+var Formats = []string{FormatPlain, FormatMarkdown, FormatHTML, FormatSRT}
+
+// Detect 根据扩展名判断文件格式，未知的扩展名作为纯文本
+func Detect(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".md", ".markdown":
+		return FormatMarkdown
+	case ".html", ".htm", ".xhtml":
+		return FormatHTML
+	case ".srt":
+		return FormatSRT
+	}
+	return FormatPlain
+}
+
+// Text 返回文档中的正文，段落之间以空行分隔
+func Text(r io.Reader, format string) (string, error) {
+	switch format {
+	case FormatPlain:
+		b, err := ioutil.ReadAll(r)
+		return string(b), err
+	case FormatMarkdown:
+		return markdownText(r)
+	case FormatHTML:
+		return htmlText(r)
+	case FormatSRT:
+		return srtText(r)
+	}
+	return "", fmt.Errorf("unknown text format %s", format)
+}
+
+var (
+	mdImage    = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink     = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdRefLink  = regexp.MustCompile(`\[([^\]]*)\]\[[^\]]*\]`)
+	mdCode     = regexp.MustCompile("`[^`]*`")
+	mdURL      = regexp.MustCompile(`<?https?://[^\s>)]+>?`)
+	mdTag      = regexp.MustCompile(`</?[A-Za-z][^>]*>`)
+	mdBlock    = regexp.MustCompile(`^\s*(#{1,6}\s+|>\s*|[-*+]\s+|\d+[.)]\s+)`)
+	mdEmphasis = regexp.MustCompile(`[*_~]{1,3}`)
+	mdRefDef   = regexp.MustCompile(`^\s*\[[^\]]+\]:\s`)
+	mdRule     = regexp.MustCompile(`^\s*([-*_]\s*){3,}$`)
+)
+
+func markdownText(r io.Reader) (string, error) {
+	var (
+		buf   strings.Builder
+		fence string
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		// 跳过代码块
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		if strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") ||
+			mdRefDef.MatchString(line) || mdRule.MatchString(line) || strings.HasPrefix(trimmed, "|") {
+			buf.WriteString("\n")
+			continue
+		}
+
+		block := mdBlock.MatchString(line)
+		line = mdBlock.ReplaceAllString(line, "")
+		line = mdImage.ReplaceAllString(line, "$1")
+		line = mdLink.ReplaceAllString(line, "$1")
+		line = mdRefLink.ReplaceAllString(line, "$1")
+		line = mdCode.ReplaceAllString(line, " ")
+		line = mdURL.ReplaceAllString(line, " ")
+		line = mdTag.ReplaceAllString(line, " ")
+		line = mdEmphasis.ReplaceAllString(line, "")
+		// 标题和列表项单独作为一句
+		if block {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		if block {
+			buf.WriteString("\n")
+		}
+	}
+	return buf.String(), scanner.Err()
+}
+
+var htmlBlocks = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "td": true, "th": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"section": true, "article": true, "header": true, "footer": true, "blockquote": true,
+	"title": true, "figcaption": true, "dt": true, "dd": true,
+}
+
+var htmlSkip = map[string]bool{
+	"script": true, "style": true, "noscript": true, "code": true, "pre": true,
+	"svg": true, "nav": true, "template": true,
+}
+
+func htmlText(r io.Reader) (string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", err
+	}
+	var (
+		buf  strings.Builder
+		walk func(*html.Node)
+	)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && htmlSkip[n.Data] {
+			return
+		}
+		if n.Type == html.TextNode {
+			buf.WriteString(n.Data)
+		}
+		block := n.Type == html.ElementNode && htmlBlocks[n.Data]
+		if block {
+			buf.WriteString("\n\n")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if block {
+			buf.WriteString("\n\n")
+		}
+	}
+	walk(doc)
+	return buf.String(), nil
+}
+
+var srtTag = regexp.MustCompile(`</?[A-Za-z][^>]*>|\{\\[^}]*\}`)
+
+// srtText 去掉序号和时间轴，一句话可能跨越多条字幕，所以把所有字幕连在一起再分句
+func srtText(r io.Reader) (string, error) {
+	var buf strings.Builder
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.Contains(line, "-->") || isNumber(line) {
+			continue
+		}
+		line = srtTag.ReplaceAllString(line, "")
+		// 对话以 - 开头
+		line = strings.TrimPrefix(line, "- ")
+		buf.WriteString(line)
+		buf.WriteString(" ")
+	}
+	return buf.String(), scanner.Err()
+}
+
+func isNumber(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "st": true, "vs": true, "etc": true,
+	"e.g": true, "i.e": true, "prof": true, "jr": true, "sr": true, "no": true, "fig": true,
+}
+
+// Sentences 把正文分为句子，空行作为段落的分隔
+func Sentences(text string) []string {
+	var sentences []string
+	for _, para := range regexp.MustCompile(`\n\s*\n`).Split(text, -1) {
+		para = strings.Join(strings.Fields(para), " ")
+		start := 0
+		runes := []rune(para)
+		for i := 0; i < len(runes); i++ {
+			if !strings.ContainsRune(".!?", runes[i]) {
+				continue
+			}
+			end := i + 1
+			for end < len(runes) && strings.ContainsRune(".!?\"')]”’", runes[end]) {
+				end++
+			}
+			if end < len(runes) && runes[end] != ' ' {
+				continue
+			}
+			if runes[i] == '.' && abbreviation(runes[start:i]) {
+				continue
+			}
+			if s := strings.TrimSpace(string(runes[start:end])); s != "" {
+				sentences = append(sentences, s)
+			}
+			start = end
+			i = end - 1
+		}
+		if s := strings.TrimSpace(string(runes[start:])); s != "" {
+			sentences = append(sentences, s)
+		}
+	}
+	return sentences
+}
+
+// abbreviation 判断句号之前的单词是否为缩写，例如 Mr. e.g.
+func abbreviation(before []rune) bool {
+	s := string(before)
+	if i := strings.LastIndex(s, " "); i >= 0 {
+		s = s[i+1:]
+	}
+	s = strings.ToLower(strings.TrimLeft(s, "(\"'"))
+	if len([]rune(s)) == 1 {
+		return true
+	}
+	return abbreviations[s]
+}
@@ -0,0 +1,98 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	idictconfig "github.com/lai323/idict/config"
+	"github.com/lai323/idict/wordset"
+	"github.com/spf13/cobra"
+)
+
+type Options struct {
+	Since *string
+	Grep  *string
+	Count *bool
+}
+
+// Show 列出查询记录，--count 时按查询次数列出单词
+func Show(config *idictconfig.Config, options Options) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if config.StoragePath == "" {
+			return errors.New("StoragePath empty")
+		}
+		since, err := ParseSince(*options.Since, time.Now())
+		if err != nil {
+			return err
+		}
+		var grep *regexp.Regexp
+		if *options.Grep != "" {
+			grep, err = regexp.Compile("(?i)" + *options.Grep)
+			if err != nil {
+				return fmt.Errorf("invalid --grep %s", err.Error())
+			}
+		}
+
+		entries, err := wordset.NewHistory(config.StoragePath).Entries()
+		if err != nil {
+			return err
+		}
+		entries = wordset.FilterHistory(entries, since, grep)
+
+		if *options.Count {
+			counts := wordset.HistoryCounts(entries)
+			var words []string
+			for w := range counts {
+				words = append(words, w)
+			}
+			sort.Slice(words, func(i, j int) bool {
+				if counts[words[i]] != counts[words[j]] {
+					return counts[words[i]] > counts[words[j]]
+				}
+				return words[i] < words[j]
+			})
+			for _, w := range words {
+				fmt.Printf("%5d  %s\n", counts[w], w)
+			}
+			return nil
+		}
+		for _, e := range entries {
+			line := fmt.Sprintf("%s  %s", time.Unix(e.Time, 0).Format("2006-01-02 15:04"), e.Word)
+			if e.Query != "" {
+				line += fmt.Sprintf("  (%s)", e.Query)
+			}
+			fmt.Println(line)
+		}
+		return nil
+	}
+}
+
+// ParseSince 解析 --since，可以是日期 2006-01-02、时间 2006-01-02 15:04，或者距离现在的时长，例如 12h 7d
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02T15:04"} {
+		t, err := time.ParseInLocation(layout, s, time.Local)
+		if err == nil {
+			return t, nil
+		}
+	}
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err == nil && days >= 0 {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid --since %q, use a date like 2006-01-02 or a duration like 12h, 7d", s)
+	}
+	return now.Add(-d), nil
+}
@@ -0,0 +1,27 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2021, 3, 10, 12, 0, 0, 0, time.Local)
+	for s, want := range map[string]time.Time{
+		"":                 {},
+		"2021-03-01":       time.Date(2021, 3, 1, 0, 0, 0, 0, time.Local),
+		"2021-03-01 08:30": time.Date(2021, 3, 1, 8, 30, 0, 0, time.Local),
+		"12h":              now.Add(-12 * time.Hour),
+		"7d":               now.AddDate(0, 0, -7),
+	} {
+		got, err := ParseSince(s, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseSince(%q) = %v, %v", s, got, err)
+		}
+	}
+	for _, s := range []string{"yesterday", "-3h", "2021-13-01"} {
+		if _, err := ParseSince(s, now); err == nil {
+			t.Errorf("ParseSince(%q) accepted", s)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	idictconfig "github.com/lai323/idict/config"
+	"github.com/lai323/idict/wordset"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+type Options struct {
+	Name        *string
+	Format      *string
+	Header      *bool
+	WordCol     *string
+	TransCol    *string
+	SentenceCol *string
+	NoteCol     *string
+	Lang        *string
+}
+
+func Import(config *idictconfig.Config, fs afero.Fs, options Options) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if config.StoragePath == "" {
+			return errors.New("StoragePath empty")
+		}
+		if *options.Name != "" && len(args) > 1 {
+			return errors.New("--name can only be used when importing one file")
+		}
+		m := wordset.WordSetManage{StoragePath: config.StoragePath}
+		cols := Columns{
+			Word:     *options.WordCol,
+			Trans:    *options.TransCol,
+			Sentence: *options.SentenceCol,
+			Note:     *options.NoteCol,
+			Header:   *options.Header,
+		}
+
+		for _, p := range args {
+			exist, err := afero.Exists(fs, p)
+			if err != nil {
+				return err
+			}
+			if !exist {
+				return fmt.Errorf("%s not exist", p)
+			}
+
+			format := *options.Format
+			if format == "" {
+				format, err = Detect(p)
+				if err != nil {
+					return err
+				}
+			}
+			name := *options.Name
+			if name == "" {
+				name = defaultName(p, format)
+			}
+
+			if format == FormatPlain {
+				err = m.Import(p, name)
+			} else {
+				records, invalid, err := Read(p, format, cols, *options.Lang)
+				if err != nil {
+					return err
+				}
+				err = m.ImportRecords(name, p, records, invalid)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func defaultName(p, format string) string {
+	if format == FormatKindle {
+		return "kindle"
+	}
+	return strings.Split(path.Base(p), ".")[0]
+}
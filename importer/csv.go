@@ -0,0 +1,139 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/lai323/idict/wordset"
+)
+
+func ReadCSV(file string, comma rune, cols Columns) ([]wordset.Record, []wordset.InvalidLine, error) {
+	filebyte, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read file %s %s", file, err.Error())
+	}
+	// 去掉 Excel 导出时可能带有的 BOM
+	filebyte = bytes.TrimPrefix(filebyte, []byte("\xef\xbb\xbf"))
+	return readFields(bytes.NewReader(filebyte), comma, cols, false)
+}
+
+func readFields(r io.Reader, comma rune, cols Columns, html bool) ([]wordset.Record, []wordset.InvalidLine, error) {
+	var (
+		records []wordset.Record
+		invalid []wordset.InvalidLine
+		idx     columnIndexes
+		err     error
+	)
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	if cols.Header {
+		header, err := reader.Read()
+		if err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+		idx, err = cols.indexes(header)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		idx, err = cols.indexes(nil)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if html {
+			for i := range fields {
+				fields[i] = StripHTML(fields[i])
+			}
+		}
+		r, ok := idx.record(fields)
+		if !ok {
+			continue
+		}
+		if !wordset.ValidWord(r.Text) {
+			invalid = append(invalid, wordset.InvalidLine{Line: line, Text: r.Text})
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, invalid, nil
+}
+
+// ReadAnkiText 读取 anki 导出的纯文本笔记，默认第一个字段为单词，第二个字段为翻译
+func ReadAnkiText(file string, cols Columns) ([]wordset.Record, []wordset.InvalidLine, error) {
+	f, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read file %s %s", file, err.Error())
+	}
+	if cols.Trans == "" {
+		cols.Trans = "2"
+	}
+
+	comma := '\t'
+	html := true
+	var body bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(f))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	headerDone := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !headerDone && strings.HasPrefix(line, "#") {
+			kv := strings.SplitN(line[1:], ":", 2)
+			if len(kv) == 2 {
+				switch strings.ToLower(kv[0]) {
+				case "separator":
+					comma = ankiSeparator(kv[1])
+				case "html":
+					html = kv[1] == "true"
+				}
+			}
+			continue
+		}
+		headerDone = true
+		body.WriteString(line)
+		body.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return readFields(&body, comma, cols, html)
+}
+
+func ankiSeparator(name string) rune {
+	switch strings.ToLower(name) {
+	case "tab":
+		return '\t'
+	case "comma":
+		return ','
+	case "semicolon":
+		return ';'
+	case "space":
+		return ' '
+	case "colon":
+		return ':'
+	case "pipe":
+		return '|'
+	}
+	if len(name) == 1 {
+		return rune(name[0])
+	}
+	return '\t'
+}
@@ -0,0 +1,189 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lai323/idict/wordset"
+	"golang.org/x/net/html"
+)
+
+const (
+	FormatPlain  = "plain"
+	FormatCSV    = "csv"
+	FormatTSV    = "tsv"
+	FormatAnki   = "anki"
+	FormatApkg   = "apkg"
+	FormatKindle = "kindle"
+)
+
+var Formats = []string{FormatPlain, FormatCSV, FormatTSV, FormatAnki, FormatApkg, FormatKindle}
+
+// Columns 指定单词、翻译、例句和备注所在的列
+// 可以是从 1 开始的列号，有表头时也可以是列名，为空表示没有这一列
+type Columns struct {
+	Word     string
+	Trans    string
+	Sentence string
+	Note     string
+	// 第一行是否为表头
+	Header bool
+}
+
+// Detect 根据扩展名和内容判断文件格式
+func Detect(file string) (string, error) {
+	switch strings.ToLower(path.Ext(file)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".tsv":
+		return FormatTSV, nil
+	case ".apkg", ".colpkg":
+		return FormatApkg, nil
+	case ".db", ".sqlite":
+		return FormatKindle, nil
+	}
+
+	filebyte, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("read file %s %s", file, err.Error())
+	}
+	// anki 导出的纯文本笔记以 #separator: 等开头，旧版本导出的是 tab 分隔的字段
+	if bytes.HasPrefix(filebyte, []byte("#separator:")) || bytes.HasPrefix(filebyte, []byte("#html:")) ||
+		bytes.Contains(filebyte, []byte("\t")) {
+		return FormatAnki, nil
+	}
+	return FormatPlain, nil
+}
+
+// Read 按 format 读取 file 中的单词
+func Read(file, format string, cols Columns, lang string) ([]wordset.Record, []wordset.InvalidLine, error) {
+	switch format {
+	case FormatCSV:
+		return ReadCSV(file, ',', cols)
+	case FormatTSV:
+		return ReadCSV(file, '\t', cols)
+	case FormatAnki:
+		return ReadAnkiText(file, cols)
+	case FormatApkg:
+		return ReadApkg(file, cols)
+	case FormatKindle:
+		return ReadKindle(file, lang)
+	case FormatPlain:
+		return nil, nil, fmt.Errorf("plain format is imported by WordSetManage.Import")
+	}
+	return nil, nil, fmt.Errorf("unknown format %s, must be one of %s", format, strings.Join(Formats, ", "))
+}
+
+// 返回列号，从 0 开始，没有这一列时为 -1
+func columnIndex(spec string, header []string) (int, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return -1, nil
+	}
+	if n, err := strconv.Atoi(spec); err == nil {
+		if n < 1 {
+			return -1, fmt.Errorf("invalid column %s, column starts from 1", spec)
+		}
+		return n - 1, nil
+	}
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), spec) {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("column %s not found in header", spec)
+}
+
+type columnIndexes struct {
+	word, trans, sentence, note int
+}
+
+func (c Columns) indexes(header []string) (columnIndexes, error) {
+	var (
+		idx columnIndexes
+		err error
+	)
+	word := c.Word
+	if word == "" {
+		word = "1"
+	}
+	if idx.word, err = columnIndex(word, header); err != nil {
+		return idx, err
+	}
+	if idx.trans, err = columnIndex(c.Trans, header); err != nil {
+		return idx, err
+	}
+	if idx.sentence, err = columnIndex(c.Sentence, header); err != nil {
+		return idx, err
+	}
+	if idx.note, err = columnIndex(c.Note, header); err != nil {
+		return idx, err
+	}
+	return idx, nil
+}
+
+func field(fields []string, i int) string {
+	if i < 0 || i >= len(fields) {
+		return ""
+	}
+	return strings.TrimSpace(fields[i])
+}
+
+// 从一行字段生成 Record，没有单词时返回 false
+func (idx columnIndexes) record(fields []string) (wordset.Record, bool) {
+	var r wordset.Record
+	r.Text = field(fields, idx.word)
+	if r.Text == "" {
+		return r, false
+	}
+	r.Translates = ParseTranslates(field(fields, idx.trans))
+	if sentence := field(fields, idx.sentence); sentence != "" {
+		r.Sentences = []wordset.Sentence{{Word: r.Text, Text: sentence}}
+	}
+	r.Note = field(fields, idx.note)
+	return r, true
+}
+
+var partOfSpeech = regexp.MustCompile(`^([a-z]+(?:\.|&[a-z]+\.)+)\s*(.+)$`)
+
+// ParseTranslates 把每行一个的翻译解析为 Translate，行首的词性如 n. vt. 会被分开
+func ParseTranslates(text string) []wordset.Translate {
+	var translates []wordset.Translate
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if m := partOfSpeech.FindStringSubmatch(line); m != nil {
+			translates = append(translates, wordset.Translate{Part: m[1], Mean: strings.TrimSpace(m[2])})
+			continue
+		}
+		translates = append(translates, wordset.Translate{Mean: line})
+	}
+	return translates
+}
+
+// StripHTML 去掉 html 标签，<br> 和块级元素换行
+func StripHTML(s string) string {
+	var buf strings.Builder
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return strings.TrimSpace(buf.String())
+		case html.TextToken:
+			buf.Write(z.Text())
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "br", "div", "p", "li":
+				buf.WriteString("\n")
+			}
+		}
+	}
+}
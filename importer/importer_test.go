@@ -0,0 +1,180 @@
+package importer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lai323/idict/wordset"
+)
+
+func recordMap(records []wordset.Record) map[string]wordset.Record {
+	m := map[string]wordset.Record{}
+	for _, r := range records {
+		m[r.Text] = r
+	}
+	return m
+}
+
+func TestDetect(t *testing.T) {
+	for file, format := range map[string]string{
+		"testdata/words.csv": FormatCSV,
+		"testdata/words.tsv": FormatTSV,
+		"testdata/anki.txt":  FormatAnki,
+		"testdata/deck.apkg": FormatApkg,
+		"testdata/vocab.db":  FormatKindle,
+	} {
+		got, err := Detect(file)
+		if err != nil || got != format {
+			t.Errorf("Detect(%s) = %s, %v", file, got, err)
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	records, invalid, err := ReadCSV("testdata/words.csv", ',', Columns{
+		Word: "word", Trans: "meaning", Sentence: "3", Note: "note", Header: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || len(invalid) != 1 || invalid[0].Text != "bad1" || invalid[0].Line != 5 {
+		t.Fatalf("records %v invalid %v", records, invalid)
+	}
+	r := recordMap(records)["idempotent"]
+	if len(r.Translates) != 2 || r.Translates[0] != (wordset.Translate{Part: "adj.", Mean: "幂等的"}) {
+		t.Errorf("translates %v", r.Translates)
+	}
+	if len(r.Sentences) != 1 || r.Sentences[0].Text != "Retrying an idempotent request is safe." {
+		t.Errorf("sentences %v", r.Sentences)
+	}
+	if r.Note != "used in our API docs" {
+		t.Errorf("note %q", r.Note)
+	}
+
+	_, _, err = ReadCSV("testdata/words.csv", ',', Columns{Word: "missing", Header: true})
+	if err == nil {
+		t.Errorf("expected error for missing column")
+	}
+}
+
+func TestReadTSV(t *testing.T) {
+	records, invalid, err := ReadCSV("testdata/words.tsv", '\t', Columns{Trans: "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := recordMap(records)
+	if len(records) != 2 || len(invalid) != 0 || r["well-known"].Translates[0].Mean != "著名的" {
+		t.Errorf("records %v invalid %v", records, invalid)
+	}
+}
+
+func TestReadAnkiText(t *testing.T) {
+	records, _, err := ReadAnkiText("testdata/anki.txt", Columns{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := recordMap(records)
+	if len(records) != 2 {
+		t.Fatalf("records %v", records)
+	}
+	if tr := r["ephemeral"].Translates; len(tr) != 2 || tr[1] != (wordset.Translate{Part: "n.", Mean: "短命植物"}) {
+		t.Errorf("ephemeral %v", tr)
+	}
+	if tr := r["transient"].Translates; len(tr) != 1 || tr[0].Part != "adj." {
+		t.Errorf("transient %v", tr)
+	}
+}
+
+func TestReadApkg(t *testing.T) {
+	records, invalid, err := ReadApkg("testdata/deck.apkg", Columns{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := recordMap(records)
+	if len(records) != 2 || len(invalid) != 1 || invalid[0].Text != "42" {
+		t.Fatalf("records %v invalid %v", records, invalid)
+	}
+	if tr := r["latency"].Translates; len(tr) != 2 || tr[1].Mean != "潜伏" {
+		t.Errorf("latency %v", tr)
+	}
+}
+
+func TestReadKindle(t *testing.T) {
+	records, _, err := ReadKindle("testdata/vocab.db", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := recordMap(records)
+	if len(records) != 2 {
+		t.Fatalf("records %v", records)
+	}
+	run := r["run"]
+	if len(run.Sentences) != 1 || run.Sentences[0].Word != "running" ||
+		run.Sentences[0].Text != "He kept running through the dark forest." {
+		t.Errorf("run %v", run.Sentences)
+	}
+	if run.Note != "from The Hobbit" {
+		t.Errorf("note %q", run.Note)
+	}
+
+	all, _, err := ReadKindle("testdata/vocab.db", "")
+	if err != nil || len(all) != 3 {
+		t.Errorf("all languages %v %v", all, err)
+	}
+}
+
+func TestReadKindleEscapedPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filebyte, err := ioutil.ReadFile("testdata/vocab.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "my vocab?#100%.db")
+	err = ioutil.WriteFile(file, filebyte, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, _, err := ReadKindle(file, "en")
+	if err != nil || len(records) != 2 {
+		t.Errorf("records %v %v", records, err)
+	}
+	// 只读打开，不会创建其他文件
+	files, err := ioutil.ReadDir(dir)
+	if err != nil || len(files) != 1 {
+		t.Errorf("files %v %v", files, err)
+	}
+}
+
+func TestImportRecords(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	records, invalid, err := ReadKindle("testdata/vocab.db", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := wordset.WordSetManage{StoragePath: dir}
+	err = m.ImportRecords("kindle", "testdata/vocab.db", records, invalid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws, _ := wordset.NewWordSet("kindle", m.WordSetDir())
+	err = ws.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	word := ws.MergeDetail(wordset.Word{Text: "run", Sentences: []wordset.Sentence{{Text: "Run!"}}})
+	if len(word.Sentences) != 2 || word.Sentences[0].Text != "He kept running through the dark forest." {
+		t.Errorf("merged sentences %v", word.Sentences)
+	}
+}
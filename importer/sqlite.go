@@ -0,0 +1,178 @@
+package importer
+
+import (
+	"archive/zip"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lai323/idict/wordset"
+	_ "modernc.org/sqlite"
+)
+
+// openSQLite 以只读方式打开数据库，文件名中的 ? # % 需要转义，相对路径会被当作主机名，需要先转为绝对路径
+func openSQLite(file string) (*sql.DB, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, fmt.Errorf("open %s %s", file, err.Error())
+	}
+	dsn := (&url.URL{Scheme: "file", Path: abs, RawQuery: "mode=ro"}).String()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open %s %s", file, err.Error())
+	}
+	return db, nil
+}
+
+// ReadKindle 读取 Kindle 生词本 vocab.db，单词使用词根，书中的原句作为例句
+// lang 为空时读取所有语言
+func ReadKindle(file string, lang string) ([]wordset.Record, []wordset.InvalidLine, error) {
+	db, err := openSQLite(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT w.word, w.stem, COALESCE(l.usage, ''), COALESCE(b.title, '')
+		FROM WORDS w
+		LEFT JOIN LOOKUPS l ON l.word_key = w.id
+		LEFT JOIN BOOK_INFO b ON b.id = l.book_key
+		WHERE ? = '' OR w.lang = ?
+		ORDER BY w.timestamp, l.timestamp`, lang, lang)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read kindle vocab %s %s", file, err.Error())
+	}
+	defer rows.Close()
+
+	var (
+		records []wordset.Record
+		invalid []wordset.InvalidLine
+	)
+	for rows.Next() {
+		var word, stem, usage, title string
+		err = rows.Scan(&word, &stem, &usage, &title)
+		if err != nil {
+			return nil, nil, err
+		}
+		text := stem
+		if text == "" {
+			text = word
+		}
+		if !wordset.ValidWord(text) {
+			invalid = append(invalid, wordset.InvalidLine{Text: text})
+			continue
+		}
+		r := wordset.Record{Text: text}
+		if usage = strings.TrimSpace(usage); usage != "" {
+			r.Sentences = []wordset.Sentence{{Word: word, Text: usage}}
+		}
+		if title != "" {
+			r.Note = "from " + title
+		}
+		records = append(records, r)
+	}
+	return records, invalid, rows.Err()
+}
+
+// ReadApkg 读取 anki 导出的 .apkg 卡组，默认第一个字段为单词，第二个字段为翻译
+func ReadApkg(file string, cols Columns) ([]wordset.Record, []wordset.InvalidLine, error) {
+	if cols.Trans == "" {
+		cols.Trans = "2"
+	}
+	idx, err := cols.indexes(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dbfile, err := extractCollection(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.Remove(dbfile)
+
+	db, err := openSQLite(dbfile)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT flds FROM notes ORDER BY id`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read anki notes %s %s", file, err.Error())
+	}
+	defer rows.Close()
+
+	var (
+		records []wordset.Record
+		invalid []wordset.InvalidLine
+		line    int
+	)
+	for rows.Next() {
+		line++
+		var flds string
+		err = rows.Scan(&flds)
+		if err != nil {
+			return nil, nil, err
+		}
+		// 字段之间以 0x1f 分隔
+		fields := strings.Split(flds, "\x1f")
+		for i := range fields {
+			fields[i] = StripHTML(fields[i])
+		}
+		r, ok := idx.record(fields)
+		if !ok {
+			continue
+		}
+		if !wordset.ValidWord(r.Text) {
+			invalid = append(invalid, wordset.InvalidLine{Line: line, Text: r.Text})
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, invalid, rows.Err()
+}
+
+// .apkg 是包含 sqlite 数据库的 zip 文件，把数据库解压到临时文件
+func extractCollection(file string) (string, error) {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return "", fmt.Errorf("open apkg %s %s", file, err.Error())
+	}
+	defer zr.Close()
+
+	var collection *zip.File
+	for _, f := range zr.File {
+		// 新版本的 collection.anki21b 使用 zstd 压缩，不支持
+		if f.Name == "collection.anki21" || (f.Name == "collection.anki2" && collection == nil) {
+			collection = f
+		}
+	}
+	if collection == nil {
+		return "", fmt.Errorf("apkg %s has no collection.anki2, export with \"Support older Anki versions\" enabled", file)
+	}
+
+	r, err := collection.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	tmp, err := ioutil.TempFile("", "idict-apkg-")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
@@ -0,0 +1,39 @@
+package lemma
+
+import (
+	"bufio"
+	_ "embed"
+	"strings"
+)
+
+// 按词频从高到低排列的常用单词，都是原形，也用于判断去掉词尾后的单词是否存在
+//
+//go:embed frequency.txt
+var frequency string
+
+var (
+	commonWords []string
+	common      = map[string]bool{}
+)
+
+func init() {
+	scanner := bufio.NewScanner(strings.NewReader(frequency))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		commonWords = append(commonWords, line)
+		common[line] = true
+	}
+}
+
+// CommonWords 返回内置的常用单词，越常用越靠前
+func CommonWords() []string {
+	return append([]string(nil), commonWords...)
+}
+
+// Common 判断是否为内置的常用单词
+func Common(word string) bool {
+	return common[word]
+}
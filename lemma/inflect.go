@@ -0,0 +1,102 @@
+package lemma
+
+import (
+	"sort"
+	"strings"
+)
+
+// 原形到不规则变形的对应，由 irregular 生成
+var irregularForms = map[string][]string{}
+
+func init() {
+	for form, word := range irregular {
+		irregularForms[word] = append(irregularForms[word], form)
+	}
+	for _, forms := range irregularForms {
+		sort.Strings(forms)
+	}
+}
+
+// Inflections 返回单词可能的变形，包括复数、第三人称单数、过去式、现在分词和比较级
+// 规则生成的变形可能有不存在的单词，只用于匹配，词组只变化第一个单词
+func Inflections(word string) []string {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" {
+		return nil
+	}
+	if i := strings.Index(word, " "); i > 0 {
+		var forms []string
+		for _, f := range Inflections(word[:i]) {
+			forms = append(forms, f+word[i:])
+		}
+		return forms
+	}
+	if strings.ContainsAny(word, "-'.") {
+		return nil
+	}
+
+	forms := append([]string(nil), irregularForms[word]...)
+	// 规则生成的变形是常用单词时是另一个单词，例如 the 和 thing，even 和 evening
+	add := func(s ...string) {
+		for _, f := range s {
+			if f != word && !contains(forms, f) && !common[f] && !keepS[f] {
+				forms = append(forms, f)
+			}
+		}
+	}
+
+	n := len(word)
+	// 两个字母的单词只有不规则变化，例如 be 和 re 不会变为 bed red
+	if n <= 2 {
+		return forms
+	}
+	last := word[n-1]
+	consonantY := n >= 2 && last == 'y' && !isVowel(word[n-2])
+	double := n >= 3 && cvc(word) && syllables(word) == 1
+	switch {
+	case consonantY:
+		add(word[:n-1]+"ies", word[:n-1]+"ied", word+"ing", word[:n-1]+"ier", word[:n-1]+"iest")
+	case strings.HasSuffix(word, "ie"):
+		add(word+"s", word+"d", word[:n-2]+"ying", word+"r", word+"st")
+	case last == 'e':
+		add(word+"s", word+"d", word+"r", word+"st")
+		if strings.HasSuffix(word, "ee") || strings.HasSuffix(word, "ye") || strings.HasSuffix(word, "oe") {
+			add(word + "ing")
+		} else {
+			add(word[:n-1] + "ing")
+		}
+	default:
+		if hasSuffix(word, "s", "x", "z", "ch", "sh", "o") {
+			add(word + "es")
+		}
+		if !hasSuffix(word, "s", "x", "z", "ch", "sh") {
+			add(word + "s")
+		}
+		add(word+"ed", word+"ing", word+"er", word+"est")
+		// 单音节以辅音、元音、辅音结尾时双写，多音节的单词是否双写取决于重音，两种都保留
+		if double || (n >= 4 && cvc(word) && !strings.ContainsRune("wxy", rune(last))) {
+			w := word + string(last)
+			add(w+"ed", w+"ing", w+"er", w+"est")
+		}
+		if last == 'c' {
+			add(word+"ked", word+"king")
+		}
+	}
+	return forms
+}
+
+// Forms 返回单词及其所有变形
+func Forms(word string) []string {
+	word = strings.ToLower(strings.TrimSpace(word))
+	return append([]string{word}, Inflections(word)...)
+}
+
+// IsForm 判断 form 是否为 word 的原形或变形，例如 ran 和 run
+func IsForm(form, word string) bool {
+	form = strings.ToLower(strings.TrimSpace(form))
+	word = strings.ToLower(strings.TrimSpace(word))
+	if form == word {
+		return true
+	}
+	return contains(Inflections(word), form) || Lemma(form) == Lemma(word)
+}
@@ -0,0 +1,239 @@
+package lemma
+
+import "strings"
+
+// 不规则变化的单词和原形
+var irregular = map[string]string{
+	"am": "be", "is": "be", "are": "be", "was": "be", "were": "be", "been": "be", "being": "be",
+	"has": "have", "had": "have", "having": "have",
+	"does": "do", "did": "do", "done": "do", "doing": "do",
+	"went": "go", "gone": "go", "goes": "go", "going": "go",
+	"arose": "arise", "arisen": "arise", "awoke": "awake", "awoken": "awake",
+	"bore": "bear", "borne": "bear", "beat": "beat", "beaten": "beat",
+	"became": "become", "began": "begin", "begun": "begin", "bent": "bend",
+	"bet": "bet", "bound": "bind", "bit": "bite", "bitten": "bite", "bled": "bleed",
+	"blew": "blow", "blown": "blow", "broke": "break", "broken": "break", "bred": "breed",
+	"brought": "bring", "built": "build", "burnt": "burn", "burst": "burst", "bought": "buy",
+	"caught": "catch", "chose": "choose", "chosen": "choose", "clung": "cling", "came": "come",
+	"crept": "creep", "dealt": "deal", "dug": "dig", "drew": "draw", "drawn": "draw",
+	"dreamt": "dream", "drank": "drink", "drunk": "drink", "drove": "drive", "driven": "drive",
+	"ate": "eat", "eaten": "eat", "fell": "fall", "fallen": "fall", "fed": "feed", "felt": "feel",
+	"fought": "fight", "found": "find", "fled": "flee", "flew": "fly", "flown": "fly",
+	"forbade": "forbid", "forbidden": "forbid", "forgot": "forget", "forgotten": "forget",
+	"forgave": "forgive", "forgiven": "forgive", "froze": "freeze", "frozen": "freeze",
+	"got": "get", "gotten": "get", "gave": "give", "given": "give", "ground": "grind",
+	"grew": "grow", "grown": "grow", "hung": "hang", "heard": "hear", "hid": "hide", "hidden": "hide",
+	"held": "hold", "hurt": "hurt", "kept": "keep", "knelt": "kneel", "knew": "know", "known": "know",
+	"laid": "lay", "led": "lead", "leant": "lean", "leapt": "leap", "learnt": "learn", "left": "leave",
+	"lent": "lend", "lay": "lie", "lain": "lie", "lit": "light", "lost": "lose", "made": "make",
+	"meant": "mean", "met": "meet", "paid": "pay", "proved": "prove", "proven": "prove",
+	"quit": "quit", "ran": "run", "rang": "ring", "rung": "ring", "rose": "rise", "risen": "rise",
+	"rode": "ride", "ridden": "ride", "said": "say", "saw": "see", "seen": "see", "sought": "seek",
+	"sold": "sell", "sent": "send", "set": "set", "shook": "shake", "shaken": "shake", "shone": "shine",
+	"shot": "shoot", "showed": "show", "shown": "show", "shrank": "shrink", "shrunk": "shrink",
+	"shut": "shut", "sang": "sing", "sung": "sing", "sank": "sink", "sunk": "sink", "sat": "sit",
+	"slept": "sleep", "slid": "slide", "slung": "sling", "spoke": "speak", "spoken": "speak",
+	"sped": "speed", "spent": "spend", "spun": "spin", "spat": "spit", "split": "split",
+	"spread": "spread", "sprang": "spring", "sprung": "spring", "stood": "stand", "stole": "steal",
+	"stolen": "steal", "stuck": "stick", "stung": "sting", "stank": "stink", "struck": "strike",
+	"strove": "strive", "striven": "strive", "swore": "swear", "sworn": "swear", "swept": "sweep",
+	"swam": "swim", "swum": "swim", "swung": "swing", "took": "take", "taken": "take",
+	"taught": "teach", "tore": "tear", "torn": "tear", "told": "tell", "thought": "think",
+	"threw": "throw", "thrown": "throw", "understood": "understand", "woke": "wake", "woken": "wake",
+	"wore": "wear", "worn": "wear", "wove": "weave", "woven": "weave", "wept": "weep", "won": "win",
+	"wound": "wind", "wrote": "write", "written": "write", "withdrew": "withdraw", "withdrawn": "withdraw",
+	"children": "child", "men": "man", "women": "woman", "people": "person", "feet": "foot",
+	"teeth": "tooth", "geese": "goose", "mice": "mouse", "lice": "louse", "oxen": "ox",
+	"data": "datum", "criteria": "criterion", "phenomena": "phenomenon", "analyses": "analysis",
+	"crises": "crisis", "theses": "thesis", "indices": "index", "matrices": "matrix",
+	"knives": "knife", "lives": "life", "wives": "wife", "leaves": "leaf", "halves": "half",
+	"selves": "self", "shelves": "shelf", "wolves": "wolf", "thieves": "thief", "loaves": "loaf",
+	"better": "good", "best": "good", "worse": "bad", "worst": "bad", "further": "far",
+	"furthest": "far", "farther": "far", "farthest": "far", "less": "little", "least": "little",
+	"more": "many", "most": "many",
+}
+
+// 不规则变形同时也是常见的原形，没有上下文时无法判断，查询时不还原，只用于生成变形
+// 例如 found 可能是 find 的过去式，也可能是 found（建立）
+var homographs = map[string]bool{
+	"left": true, "found": true, "saw": true, "ground": true, "rose": true, "lay": true, "bit": true,
+	"fell": true, "wound": true, "data": true, "better": true, "more": true, "less": true, "leaves": true,
+	"lives": true, "felt": true, "bore": true, "bound": true, "stole": true, "spat": true, "rung": true,
+	"shot": true, "spoke": true, "drunk": true, "lit": true, "best": true, "most": true, "least": true,
+	"further": true, "people": true, "media": true, "won": true,
+}
+
+// 看起来像变形但是原形的常见单词，常用单词列表中的单词也都是原形
+var keepS = map[string]bool{
+	"lens": true, "united": true,
+	"this": true, "his": true, "its": true, "yes": true, "us": true, "thus": true, "plus": true,
+	"news": true, "series": true, "species": true, "means": true, "always": true, "perhaps": true,
+	"whereas": true, "across": true, "besides": true, "sometimes": true, "towards": true,
+	"afterwards": true, "nowadays": true, "mathematics": true, "physics": true, "politics": true,
+}
+
+// Lemmatizer 把单词还原为原形，规则去掉词尾后的单词只有是常用单词或 Known 中的单词时才使用，
+// 避免把 evening united 这样的单词还原为其他单词，Known 可以为 nil
+type Lemmatizer struct {
+	Known func(string) bool
+	// 没有已知的原形时使用规则最可能的结果，用于从文章中提取生词等结果会再经过用户确认的情况
+	Guess bool
+}
+
+// Lemma 只使用不规则变化和常用单词返回单词的原形
+func Lemma(word string) string {
+	return Lemmatizer{}.Lemma(word)
+}
+
+func (l Lemmatizer) Lemma(word string) string {
+	word = strings.ToLower(word)
+	if homographs[word] {
+		return word
+	}
+	if w, ok := irregular[word]; ok {
+		return w
+	}
+	if keepS[word] || common[word] || strings.ContainsAny(word, " -'") {
+		return word
+	}
+	candidates := Candidates(word)
+	for _, c := range candidates {
+		if c != word && (common[c] || l.Known != nil && l.Known(c)) {
+			return c
+		}
+	}
+	if l.Guess && len(candidates) != 0 {
+		return candidates[0]
+	}
+	return word
+}
+
+// Candidates 返回单词可能的原形，按可能性排列，可能有不存在的单词，不是变形时返回空
+func Candidates(word string) []string {
+	if keepS[word] {
+		return nil
+	}
+	var c []string
+	add := func(s ...string) {
+		for _, w := range s {
+			if len(w) >= 2 && hasVowel(w) && !contains(c, w) {
+				c = append(c, w)
+			}
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		add(word[:len(word)-3]+"y", word[:len(word)-1])
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "zzes"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"), strings.HasSuffix(word, "oes"):
+		add(word[:len(word)-2], word[:len(word)-1])
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+	case strings.HasSuffix(word, "s") && len(word) > 3:
+		add(word[:len(word)-1])
+	case strings.HasSuffix(word, "ied") && len(word) > 4:
+		add(word[:len(word)-3]+"y", word[:len(word)-1])
+	case strings.HasSuffix(word, "eed"):
+		// speed exceed 等是原形
+		if len(word) > 5 && !strings.HasSuffix(word, "ceed") {
+			add(word[:len(word)-1])
+		}
+	// 词干中需要有元音，例如 thing bring shed 不是变形
+	case strings.HasSuffix(word, "ed") && len(word) > 3 && hasVowel(word[:len(word)-2]):
+		add(stem(word[:len(word)-2])...)
+		add(word[:len(word)-1])
+	case strings.HasSuffix(word, "ing") && len(word) > 4 && hasVowel(word[:len(word)-3]):
+		add(stem(word[:len(word)-3])...)
+	case strings.HasSuffix(word, "est") && len(word) > 5:
+		add(comparative(word[:len(word)-3])...)
+	case strings.HasSuffix(word, "er") && len(word) > 4:
+		add(comparative(word[:len(word)-2])...)
+	}
+	// 比较级的规则容易误判，例如 water number，只作为备选
+	if strings.HasSuffix(word, "er") || strings.HasSuffix(word, "est") {
+		return append([]string{word}, c...)
+	}
+	return c
+}
+
+// stem 处理去掉 ed 或 ing 之后的词干，规则参考 Porter stemmer 的 1b 步骤，第一个为最可能的原形
+func stem(s string) []string {
+	n := len(s)
+	withE := []string{s + "e", s}
+	switch {
+	case n >= 2 && s[n-1] == s[n-2] && !strings.ContainsRune("aeiouylsz", rune(s[n-1])):
+		return []string{s[:n-1], s}
+	case n >= 3 && strings.HasSuffix(s, "at") && !strings.ContainsRune("aeo", rune(s[n-3])):
+		return withE
+	case strings.HasSuffix(s, "creat") || n >= 5 && strings.HasSuffix(s, "eat") && strings.ContainsRune("dmn", rune(s[n-4])):
+		// create ideate permeate delineate，其他以 eat 结尾的是 treat repeat 等原形
+		return withE
+	case hasSuffix(s, "bl", "iz", "yz", "uc", "ur", "v", "dg", "ang", "nc", "rc"):
+		return withE
+	case strings.HasSuffix(s, "s"):
+		// focus pass 等以 s 结尾的原形不需要加 e
+		if n >= 3 && (s[n-2] == 's' || s[n-2] == 'u' && !isVowel(s[n-3])) {
+			return []string{s, s + "e"}
+		}
+		return withE
+	case n >= 3 && cvc(s) && syllables(s) == 1:
+		return withE
+	}
+	return []string{s, s + "e"}
+}
+
+func hasSuffix(s string, suffix ...string) bool {
+	for _, x := range suffix {
+		if strings.HasSuffix(s, x) {
+			return true
+		}
+	}
+	return false
+}
+
+func comparative(s string) []string {
+	n := len(s)
+	switch {
+	case n >= 2 && s[n-1] == 'i':
+		return []string{s[:n-1] + "y"}
+	case n >= 2 && s[n-1] == s[n-2] && !strings.ContainsRune("aeiouyls", rune(s[n-1])):
+		return []string{s[:n-1], s}
+	}
+	return []string{s, s + "e"}
+}
+
+func isVowel(b byte) bool {
+	return strings.IndexByte("aeiou", b) >= 0
+}
+
+func hasVowel(s string) bool {
+	return strings.ContainsAny(s, "aeiouy")
+}
+
+// cvc 判断是否以辅音、元音、辅音结尾，最后的辅音不是 w x y
+func cvc(s string) bool {
+	n := len(s)
+	return !isVowel(s[n-3]) && isVowel(s[n-2]) && !isVowel(s[n-1]) && !strings.ContainsRune("wxy", rune(s[n-1]))
+}
+
+func syllables(s string) int {
+	count := 0
+	prev := false
+	for i := 0; i < len(s); i++ {
+		v := isVowel(s[i])
+		if v && !prev {
+			count++
+		}
+		prev = v
+	}
+	return count
+}
+
+func contains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package lemma
+
+import "testing"
+
+func TestLemma(t *testing.T) {
+	// 去掉词尾后的单词需要是已知的单词，其中 hop stopp visite 等用于检查规则的顺序
+	known := map[string]bool{
+		"run": true, "visit": true, "visite": true, "call": true, "hop": true, "hope": true, "box": true,
+		"watch": true, "cat": true, "like": true, "want": true, "give": true, "agree": true,
+		"change": true, "receive": true, "treat": true, "defeat": true, "graduate": true, "float": true,
+		"eat": true, "pass": true, "use": true,
+		// 容易被误还原成的单词
+		"the": true, "even": true, "morn": true, "unit": true, "she": true, "len": true, "re": true,
+	}
+	l := Lemmatizer{Known: func(w string) bool { return known[w] }}
+	for word, want := range map[string]string{
+		"running": "run", "makes": "make", "made": "make", "eating": "eat", "visited": "visit",
+		"stopped": "stop", "called": "call", "passed": "pass", "hoping": "hope", "studies": "study",
+		"studied": "study", "boxes": "box", "watches": "watch", "cats": "cat", "used": "use",
+		"liked": "like", "wanted": "want", "giving": "give", "children": "child", "this": "this",
+		"analysis": "analysis", "news": "news", "agreed": "agree", "exceed": "exceed", "changed": "change",
+		"focused": "focus", "received": "receive", "water": "water", "take off": "take off",
+		"created": "create", "creates": "create", "creating": "create", "treated": "treat",
+		"defeated": "defeat", "graduated": "graduate", "floated": "float",
+		// 同时也是常见原形的变形不还原
+		"found": "found", "left": "left", "saw": "saw", "data": "data", "better": "better",
+		// 不是变形的单词
+		"thing": "thing", "evening": "evening", "morning": "morning", "united": "united", "shed": "shed",
+		"bring": "bring", "during": "during", "indeed": "indeed", "lens": "lens", "bed": "bed", "red": "red",
+	} {
+		if got := l.Lemma(word); got != want {
+			t.Errorf("Lemma(%q) = %q, want %q", word, got, want)
+		}
+	}
+	// 没有已知的单词时只使用不规则变化和常用单词
+	for word, want := range map[string]string{"ran": "run", "running": "running", "lens": "lens", "made": "make"} {
+		if got := Lemma(word); got != want {
+			t.Errorf("Lemma(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestLemmaKnown(t *testing.T) {
+	known := map[string]bool{"big": true, "bigger": true, "fast": true, "water": true}
+	l := Lemmatizer{Known: func(w string) bool { return known[w] }}
+	for word, want := range map[string]string{
+		"bigger": "big", "faster": "fast", "water": "water", "number": "number",
+	} {
+		if got := l.Lemma(word); got != want {
+			t.Errorf("Lemma(%q) = %q, want %q", word, got, want)
+		}
+	}
+	guess := Lemmatizer{Guess: true}
+	if got := guess.Lemma("retries"); got != "retry" {
+		t.Errorf("guess retries = %q", got)
+	}
+	if got := guess.Lemma("thing"); got != "thing" {
+		t.Errorf("guess thing = %q", got)
+	}
+}
+
+func TestInflections(t *testing.T) {
+	for word, want := range map[string][]string{
+		"run":      {"ran", "runs", "running"},
+		"make":     {"made", "makes", "making"},
+		"study":    {"studies", "studied", "studying"},
+		"stop":     {"stops", "stopped", "stopping"},
+		"visit":    {"visits", "visited", "visiting"},
+		"watch":    {"watches", "watched"},
+		"lie":      {"lying", "lies"},
+		"child":    {"children"},
+		"take off": {"took off", "taking off", "takes off"},
+		"big":      {"bigger", "biggest"},
+		"panic":    {"panicked"},
+	} {
+		forms := Inflections(word)
+		for _, w := range want {
+			if !contains(forms, w) {
+				t.Errorf("Inflections(%q) = %v, missing %q", word, forms, w)
+			}
+		}
+	}
+	for word, not := range map[string]string{"be": "bed", "re": "red", "the": "thing", "even": "evening"} {
+		if forms := Inflections(word); contains(forms, not) {
+			t.Errorf("Inflections(%q) = %v, has %q", word, forms, not)
+		}
+	}
+	if !IsForm("running", "run") || !IsForm("ran", "run") || IsForm("rain", "run") {
+		t.Errorf("IsForm")
+	}
+	if IsForm("bed", "be") || IsForm("red", "re") || IsForm("thing", "the") || IsForm("evening", "even") {
+		t.Errorf("IsForm matches unrelated words")
+	}
+}
@@ -0,0 +1,30 @@
+package lsp
+
+import (
+	"errors"
+	"os"
+
+	idictconfig "github.com/lai323/idict/config"
+	"github.com/lai323/idict/dict"
+	"github.com/lai323/idict/wordset"
+	"github.com/spf13/cobra"
+)
+
+type Options struct {
+	WordSet *string
+}
+
+// Run 通过标准输入输出提供语言服务，后台服务运行时使用后台服务查询
+func Run(config *idictconfig.Config, opts Options) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if config.StoragePath == "" {
+			return errors.New("StoragePath empty")
+		}
+		cli, err := dict.NewClient(*config)
+		if err != nil {
+			return err
+		}
+		m := wordset.WordSetManage{StoragePath: config.StoragePath, RestudyInterval: config.RestudyInterval}
+		return NewServer(cli, m, *opts.WordSet).Serve(os.Stdin, os.Stdout)
+	}
+}
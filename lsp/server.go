@@ -0,0 +1,392 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/lai323/idict/dict"
+	"github.com/lai323/idict/wordset"
+)
+
+// AddToWordSetCommand 为 code action 使用的命令，参数为单词和单词本
+const AddToWordSetCommand = "idict.addToWordSet"
+
+const completionLimit = 50
+
+// Server 为编辑器提供悬停翻译、单词本补全和加入单词本的 code action
+// 文档只支持全量同步，位置按 UTF-16 计算
+type Server struct {
+	client  dict.DictClient
+	manage  wordset.WordSetManage
+	wordset string
+
+	wmu sync.Mutex
+	out io.Writer
+	wg  sync.WaitGroup
+
+	docs  map[string][]string
+	words []string
+}
+
+// NewServer 创建 Server，name 为 code action 加入的单词本
+func NewServer(cli dict.DictClient, m wordset.WordSetManage, name string) *Server {
+	if name == "" {
+		name = wordset.DefaultWordSet
+	}
+	return &Server{client: cli, manage: m, wordset: name, docs: map[string][]string{}}
+}
+
+// Serve 处理消息直到收到 exit 或者 r 结束
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.out = w
+	defer s.wg.Wait()
+	br := bufio.NewReader(r)
+	for {
+		body, err := readMessage(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("lsp read %s", err.Error())
+		}
+		var msg message
+		err = json.Unmarshal(body, &msg)
+		if err != nil {
+			s.replyError(nil, codeParseError, err)
+			continue
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		s.handle(msg)
+	}
+}
+
+func (s *Server) write(v interface{}) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	writeMessage(s.out, v)
+}
+
+func (s *Server) reply(id json.RawMessage, result interface{}) {
+	s.write(response{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+func (s *Server) replyError(id json.RawMessage, code int, err error) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	s.write(errorResponse{JSONRPC: "2.0", ID: id, Error: responseError{Code: code, Message: err.Error()}})
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) showMessage(typ int, text string) {
+	s.notify("window/showMessage", showMessageParams{Type: typ, Message: text})
+}
+
+func (s *Server) handle(msg message) {
+	var (
+		result interface{}
+		err    error
+	)
+	switch msg.Method {
+	case "initialize":
+		result, err = s.initialize()
+	case "shutdown":
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err = json.Unmarshal(msg.Params, &p); err == nil {
+			s.docs[p.TextDocument.URI] = splitLines(p.TextDocument.Text)
+		}
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err = json.Unmarshal(msg.Params, &p); err == nil && len(p.ContentChanges) != 0 {
+			s.docs[p.TextDocument.URI] = splitLines(p.ContentChanges[len(p.ContentChanges)-1].Text)
+		}
+	case "textDocument/didClose":
+		var p didCloseParams
+		if err = json.Unmarshal(msg.Params, &p); err == nil {
+			delete(s.docs, p.TextDocument.URI)
+		}
+	case "textDocument/hover":
+		var p positionParams
+		if err = json.Unmarshal(msg.Params, &p); err == nil {
+			// 没有缓存时需要联网，不阻塞其他请求
+			line := s.line(p.TextDocument.URI, p.Position.Line)
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.reply(msg.ID, s.hover(line, p.Position))
+			}()
+			return
+		}
+	case "textDocument/completion":
+		var p positionParams
+		if err = json.Unmarshal(msg.Params, &p); err == nil {
+			result = s.completion(p)
+		}
+	case "textDocument/codeAction":
+		var p codeActionParams
+		if err = json.Unmarshal(msg.Params, &p); err == nil {
+			result = s.codeAction(p)
+		}
+	case "workspace/executeCommand":
+		var p executeCommandParams
+		if err = json.Unmarshal(msg.Params, &p); err == nil {
+			err = s.executeCommand(p)
+		}
+	default:
+		if msg.isRequest() {
+			s.replyError(msg.ID, codeMethodNotFound, fmt.Errorf("method %s not found", msg.Method))
+		}
+		return
+	}
+	if !msg.isRequest() {
+		return
+	}
+	if err != nil {
+		code := codeInternalError
+		switch err.(type) {
+		case *json.UnmarshalTypeError, invalidParams:
+			code = codeInvalidParams
+		}
+		s.replyError(msg.ID, code, err)
+		return
+	}
+	s.reply(msg.ID, result)
+}
+
+func (s *Server) initialize() (interface{}, error) {
+	err := s.loadWords()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync":       1,
+			"hoverProvider":          true,
+			"completionProvider":     map[string]interface{}{},
+			"codeActionProvider":     true,
+			"executeCommandProvider": map[string]interface{}{"commands": []string{AddToWordSetCommand}},
+		},
+		"serverInfo": map[string]string{"name": "idict"},
+	}, nil
+}
+
+// loadWords 读取所有单词本中的单词用于补全
+func (s *Server) loadWords() error {
+	names, err := s.manage.Names()
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	var words []string
+	for _, name := range names {
+		ws, err := s.manage.Load(name)
+		if err != nil {
+			return err
+		}
+		for _, w := range ws.SortedWords() {
+			if !seen[w] {
+				seen[w] = true
+				words = append(words, w)
+			}
+		}
+	}
+	sort.Strings(words)
+	s.words = words
+	return nil
+}
+
+func (s *Server) line(uri string, line int) string {
+	lines := s.docs[uri]
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+	return lines[line]
+}
+
+func (s *Server) hover(line string, pos position) interface{} {
+	text, start, end := wordAt(line, pos.Character)
+	if text == "" {
+		return nil
+	}
+	err, word := s.client.Cache(text)
+	if err != nil || (len(word.Translates) == 0 && word.PronounceUS.Phonetic == "" && word.PronounceUK.Phonetic == "") {
+		return nil
+	}
+	return hover{
+		Contents: markupContent{Kind: "markdown", Value: hoverText(word)},
+		Range: textRange{
+			Start: position{Line: pos.Line, Character: start},
+			End:   position{Line: pos.Line, Character: end},
+		},
+	}
+}
+
+func hoverText(word wordset.Word) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s**", word.Text)
+	if word.PronounceUS.Phonetic != "" {
+		fmt.Fprintf(&b, "  US %s", word.PronounceUS.Phonetic)
+	}
+	if word.PronounceUK.Phonetic != "" {
+		fmt.Fprintf(&b, "  UK %s", word.PronounceUK.Phonetic)
+	}
+	b.WriteString("\n")
+	for _, t := range word.Translates {
+		fmt.Fprintf(&b, "\n- %s", strings.TrimSpace(t.Part+" "+t.Mean))
+	}
+	return b.String()
+}
+
+func (s *Server) completion(p positionParams) completionList {
+	list := completionList{Items: []completionItem{}}
+	prefix := prefixAt(s.line(p.TextDocument.URI, p.Position.Line), p.Position.Character)
+	if prefix == "" {
+		return list
+	}
+	lower := strings.ToLower(prefix)
+	upper := unicode.IsUpper(rune(prefix[0]))
+	for i := sort.SearchStrings(s.words, lower); i < len(s.words) && strings.HasPrefix(s.words[i], lower); i++ {
+		if len(list.Items) == completionLimit {
+			list.IsIncomplete = true
+			break
+		}
+		label := s.words[i]
+		// 句首大写时补全也大写
+		if upper {
+			label = strings.ToUpper(label[:1]) + label[1:]
+		}
+		list.Items = append(list.Items, completionItem{Label: label, Kind: completionKindText})
+	}
+	return list
+}
+
+func (s *Server) codeAction(p codeActionParams) []codeAction {
+	actions := []codeAction{}
+	text, _, _ := wordAt(s.line(p.TextDocument.URI, p.Range.Start.Line), p.Range.Start.Character)
+	if text == "" || !wordset.ValidWord(text) {
+		return actions
+	}
+	text = wordset.NormalizeWord(text)
+	return append(actions, codeAction{
+		Title: fmt.Sprintf("Add %q to wordset %s", text, s.wordset),
+		Kind:  "quickfix",
+		Command: command{
+			Title:     "Add to wordset",
+			Command:   AddToWordSetCommand,
+			Arguments: []interface{}{text, s.wordset},
+		},
+	})
+}
+
+// invalidParams 为请求参数错误，回复 InvalidParams
+type invalidParams struct {
+	error
+}
+
+func (s *Server) executeCommand(p executeCommandParams) error {
+	if p.Command != AddToWordSetCommand {
+		return invalidParams{fmt.Errorf("unknown command %s", p.Command)}
+	}
+	var text, name string
+	if len(p.Arguments) == 0 {
+		return invalidParams{fmt.Errorf("%s missing word argument", p.Command)}
+	}
+	err := json.Unmarshal(p.Arguments[0], &text)
+	if err != nil {
+		return invalidParams{fmt.Errorf("%s word argument %s", p.Command, err.Error())}
+	}
+	if len(p.Arguments) > 1 {
+		err = json.Unmarshal(p.Arguments[1], &name)
+		if err != nil {
+			return invalidParams{fmt.Errorf("%s wordset argument %s", p.Command, err.Error())}
+		}
+	}
+	if name == "" {
+		name = s.wordset
+	}
+	if !wordset.ValidName(name) {
+		return invalidParams{fmt.Errorf("invalid wordset %q", name)}
+	}
+	added, invalid, err := s.manage.AddWords(name, []string{text})
+	if err != nil {
+		s.showMessage(messageError, err.Error())
+		return err
+	}
+	switch {
+	case len(invalid) != 0:
+		return invalidParams{fmt.Errorf("invalid word %q", text)}
+	case added == 0:
+		s.showMessage(messageInfo, fmt.Sprintf("%s already in %s", text, name))
+	default:
+		s.showMessage(messageInfo, fmt.Sprintf("added %s into %s", text, name))
+	}
+	return s.loadWords()
+}
+
+func splitLines(text string) []string {
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+	return lines
+}
+
+func isLetter(u uint16) bool {
+	return 'a' <= u && u <= 'z' || 'A' <= u && u <= 'Z'
+}
+
+func isWordUnit(u uint16) bool {
+	return isLetter(u) || u == '\'' || u == '’' || u == '-'
+}
+
+// wordAt 返回 character 所在的英文单词和单词的范围，character 为 UTF-16 的位置
+func wordAt(line string, character int) (string, int, int) {
+	units := utf16.Encode([]rune(line))
+	if character > len(units) {
+		character = len(units)
+	}
+	if character < 0 {
+		character = 0
+	}
+	start, end := character, character
+	for start > 0 && isWordUnit(units[start-1]) {
+		start--
+	}
+	for end < len(units) && isWordUnit(units[end]) {
+		end++
+	}
+	// 去掉两端的引号和连字符
+	for start < end && !isLetter(units[start]) {
+		start++
+	}
+	for end > start && !isLetter(units[end-1]) {
+		end--
+	}
+	return string(utf16.Decode(units[start:end])), start, end
+}
+
+// prefixAt 返回光标前的字母
+func prefixAt(line string, character int) string {
+	units := utf16.Encode([]rune(line))
+	if character > len(units) {
+		character = len(units)
+	}
+	start := character
+	for start > 0 && isLetter(units[start-1]) {
+		start--
+	}
+	return string(utf16.Decode(units[start:character]))
+}
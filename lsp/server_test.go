@@ -0,0 +1,243 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lai323/idict/wordset"
+)
+
+type fakeClient struct{}
+
+func (fakeClient) Fetch(text string) (error, wordset.Word)      { return fakeClient{}.Cache(text) }
+func (fakeClient) FetchCache(text string) (error, wordset.Word) { return fakeClient{}.Cache(text) }
+func (fakeClient) Guess(text string) (error, []wordset.GuessWord) {
+	return nil, nil
+}
+func (fakeClient) Cache(text string) (error, wordset.Word) {
+	if text == "missing" {
+		return errors.New("not found"), wordset.Word{}
+	}
+	return nil, wordset.Word{
+		Text:        wordset.NormalizeWord(text),
+		PronounceUS: wordset.Pronounce{Phonetic: "/ˈæpl/"},
+		Translates:  []wordset.Translate{{Part: "n.", Mean: "苹果"}},
+	}
+}
+
+// testClient 按顺序发送请求并读取回复，期间收到的通知保存在 notifications
+type testClient struct {
+	t             *testing.T
+	w             io.Writer
+	r             *bufio.Reader
+	id            int
+	notifications []notification
+}
+
+func (c *testClient) send(v interface{}) {
+	c.t.Helper()
+	err := writeMessage(c.w, v)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *testClient) notify(method string, params interface{}) {
+	c.send(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (c *testClient) call(method string, params interface{}, result interface{}) *responseError {
+	c.t.Helper()
+	c.id++
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "id": c.id, "method": method, "params": params})
+	for {
+		body, err := readMessage(c.r)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		var msg struct {
+			ID     *int            `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+			Result json.RawMessage `json:"result"`
+			Error  *responseError  `json:"error"`
+		}
+		err = json.Unmarshal(body, &msg)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		if msg.Method != "" {
+			var p showMessageParams
+			json.Unmarshal(msg.Params, &p)
+			c.notifications = append(c.notifications, notification{Method: msg.Method, Params: p})
+			continue
+		}
+		if msg.ID == nil || *msg.ID != c.id {
+			c.t.Fatalf("unexpected response %s", body)
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil {
+			err = json.Unmarshal(msg.Result, result)
+			if err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		return nil
+	}
+}
+
+func TestServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	m := wordset.WordSetManage{StoragePath: dir}
+	_, _, err = m.AddWords(wordset.DefaultWordSet, []string{"apple", "application", "banana"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inr, inw := io.Pipe()
+	outr, outw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer(fakeClient{}, m, "").Serve(inr, outw)
+		outw.Close()
+	}()
+	c := &testClient{t: t, w: inw, r: bufio.NewReader(outr)}
+
+	var init struct {
+		Capabilities struct {
+			HoverProvider bool `json:"hoverProvider"`
+		} `json:"capabilities"`
+	}
+	if e := c.call("initialize", map[string]interface{}{}, &init); e != nil || !init.Capabilities.HoverProvider {
+		t.Fatalf("initialize %+v %v", init, e)
+	}
+	c.notify("initialized", map[string]interface{}{})
+	uri := "file:///doc.md"
+	c.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "languageId": "markdown", "version": 1, "text": "Use an Apple a day.\r\n中文 apple’s\nApp"},
+	})
+
+	hoverAt := func(line, character int) *hover {
+		t.Helper()
+		var h *hover
+		e := c.call("textDocument/hover", positionParams{
+			TextDocument: textDocumentIdentifier{URI: uri},
+			Position:     position{Line: line, Character: character},
+		}, &h)
+		if e != nil {
+			t.Fatal(e.Message)
+		}
+		return h
+	}
+	h := hoverAt(0, 9)
+	if h == nil || !strings.Contains(h.Contents.Value, "**apple**") || !strings.Contains(h.Contents.Value, "n. 苹果") ||
+		!strings.Contains(h.Contents.Value, "/ˈæpl/") || h.Range.Start.Character != 7 || h.Range.End.Character != 12 {
+		t.Errorf("hover %+v", h)
+	}
+	// 位置按 UTF-16 计算
+	h = hoverAt(1, 4)
+	if h == nil || h.Range.Start.Character != 3 || h.Range.End.Character != 10 {
+		t.Errorf("hover utf16 %+v", h)
+	}
+	if h = hoverAt(1, 0); h != nil {
+		t.Errorf("hover cjk %+v", h)
+	}
+
+	completeAt := func(line, character int) []string {
+		t.Helper()
+		var list completionList
+		e := c.call("textDocument/completion", positionParams{
+			TextDocument: textDocumentIdentifier{URI: uri},
+			Position:     position{Line: line, Character: character},
+		}, &list)
+		if e != nil {
+			t.Fatal(e.Message)
+		}
+		var labels []string
+		for _, item := range list.Items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+	if labels := completeAt(2, 3); strings.Join(labels, ",") != "Apple,Application" {
+		t.Errorf("completion %v", labels)
+	}
+
+	var actions []codeAction
+	e := c.call("textDocument/codeAction", codeActionParams{
+		TextDocument: textDocumentIdentifier{URI: uri},
+		Range:        textRange{Start: position{Line: 0, Character: 16}, End: position{Line: 0, Character: 16}},
+	}, &actions)
+	if e != nil || len(actions) != 1 || actions[0].Command.Command != AddToWordSetCommand ||
+		actions[0].Command.Arguments[0] != "day" || actions[0].Command.Arguments[1] != "default" {
+		t.Fatalf("code action %+v %v", actions, e)
+	}
+	e = c.call("workspace/executeCommand", executeCommandParams{
+		Command:   actions[0].Command.Command,
+		Arguments: []json.RawMessage{json.RawMessage(`"day"`), json.RawMessage(`"default"`)},
+	}, nil)
+	if e != nil {
+		t.Fatal(e.Message)
+	}
+	if len(c.notifications) != 1 || c.notifications[0].Params.(showMessageParams).Message != "added day into default" {
+		t.Errorf("notifications %+v", c.notifications)
+	}
+	for _, args := range [][]json.RawMessage{nil, {json.RawMessage(`1`)}, {json.RawMessage(`"day"`), json.RawMessage(`["default"]`)}} {
+		e = c.call("workspace/executeCommand", executeCommandParams{Command: AddToWordSetCommand, Arguments: args}, nil)
+		if e == nil || e.Code != codeInvalidParams {
+			t.Errorf("arguments %s %+v", args, e)
+		}
+	}
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 2},
+		"contentChanges": []map[string]string{{"text": "da"}},
+	})
+	if labels := completeAt(0, 2); strings.Join(labels, ",") != "day" {
+		t.Errorf("completion after add %v", labels)
+	}
+
+	if e := c.call("textDocument/definition", map[string]interface{}{}, nil); e == nil || e.Code != codeMethodNotFound {
+		t.Errorf("unknown method %+v", e)
+	}
+	if e := c.call("shutdown", nil, nil); e != nil {
+		t.Fatal(e.Message)
+	}
+	c.notify("exit", nil)
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWordAt(t *testing.T) {
+	cases := []struct {
+		line      string
+		character int
+		word      string
+	}{
+		{"an apple", 5, "apple"},
+		{"an apple", 3, "apple"},
+		{"an apple", 8, "apple"},
+		{"an apple", 2, "an"},
+		{"'well-known'", 1, "well-known"},
+		{"don't", 4, "don't"},
+		{"a  b", 2, ""},
+		{"中文apple", 2, "apple"},
+	}
+	for _, c := range cases {
+		if word, _, _ := wordAt(c.line, c.character); word != c.word {
+			t.Errorf("wordAt(%q, %d) = %q, want %q", c.line, c.character, word, c.word)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package practice
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lai323/idict/lemma"
+	"github.com/lai323/idict/wordset"
+)
+
+// 填空中其他位置出现的单词用下划线代替
+const clozeMask = "_____"
+
+// 匹配句子中的单词或词组及其变形，忽略大小写，词组中的空格可以匹配任意空白
+func clozeRegexp(word string) *regexp.Regexp {
+	forms := lemma.Forms(wordset.NormalizeWord(word))
+	// 较长的变形在前，避免只匹配到一部分
+	sort.SliceStable(forms, func(i, j int) bool { return len(forms[i]) > len(forms[j]) })
+	var alts []string
+	for _, form := range forms {
+		var tokens []string
+		for _, t := range strings.Fields(form) {
+			tokens = append(tokens, strings.Replace(regexp.QuoteMeta(t), "'", "['’]", -1))
+		}
+		alts = append(alts, strings.Join(tokens, `\s+`))
+	}
+	return regexp.MustCompile(`(?i)(^|[^A-Za-z])(` + strings.Join(alts, "|") + `)($|[^A-Za-z])`)
+}
+
+// splitCloze 在第一次出现 word 或其变形的位置把句子分成前后两部分，之后出现的都用下划线代替
+// 没有找到时 found 为 false
+func splitCloze(sentence, word string) (start, end string, found bool) {
+	re := clozeRegexp(word)
+	loc := re.FindStringSubmatchIndex(sentence)
+	if loc == nil {
+		return sentence, "", false
+	}
+	return sentence[:loc[4]], maskCloze(re, sentence[loc[5]:]), true
+}
+
+func maskCloze(re *regexp.Regexp, s string) string {
+	for {
+		loc := re.FindStringSubmatchIndex(s)
+		if loc == nil {
+			return s
+		}
+		s = s[:loc[4]] + clozeMask + s[loc[5]:]
+	}
+}
+
+// 比较答案时忽略大小写和多余的空白，inflection 为 true 时单词的变形也算正确
+func answerMatch(answer, word string, inflection bool) bool {
+	answer, word = wordset.NormalizeWord(answer), wordset.NormalizeWord(word)
+	if answer == word {
+		return true
+	}
+	return inflection && lemma.IsForm(answer, word)
+}
+
+// leaksAnswer 判断释义中是否包含单词或其变形，例如“run的现在分词”
+// 只按完整的单词匹配，避免 a 这样的短词匹配到 adj. 等
+func leaksAnswer(mean, word string) bool {
+	return clozeRegexp(word).MatchString(mean)
+}
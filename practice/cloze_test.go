@@ -0,0 +1,45 @@
+package practice
+
+import "testing"
+
+func TestSplitCloze(t *testing.T) {
+	for _, c := range []struct {
+		sentence, word, start, end string
+		found                      bool
+	}{
+		{"The plane will take off soon.", "take off", "The plane will ", " soon.", true},
+		{"The plane will Take\toff soon.", "take off", "The plane will ", " soon.", true},
+		{"It is a well-known fact.", "well-known", "It is a ", " fact.", true},
+		{"It's six o’clock.", "o'clock", "It's six ", ".", true},
+		{"A cat is not a category.", "cat", "A ", " is not a category.", true},
+		{"Category first, then cat.", "cat", "Category first, then ", ".", true},
+		{"No match here.", "apple", "No match here.", "", false},
+		{"He was running late.", "run", "He was ", " late.", true},
+		{"She took off and they take off.", "take off", "She ", " and they _____.", true},
+		{"Run, ran, runs!", "run", "", ", _____, _____!", true},
+	} {
+		start, end, found := splitCloze(c.sentence, c.word)
+		if start != c.start || end != c.end || found != c.found {
+			t.Errorf("splitCloze(%q, %q) = %q, %q, %v", c.sentence, c.word, start, end, found)
+		}
+	}
+}
+
+func TestAnswerMatch(t *testing.T) {
+	if !answerMatch(" Take  Off ", "take off", false) {
+		t.Errorf("phrase answer not matched")
+	}
+	if answerMatch("takeoff", "take off", false) {
+		t.Errorf("answer without space matched")
+	}
+	if answerMatch("running", "run", false) || !answerMatch("running", "run", true) || answerMatch("rain", "run", true) {
+		t.Errorf("inflection answer")
+	}
+}
+
+func TestLeaksAnswer(t *testing.T) {
+	if !leaksAnswer("run的现在分词", "run") || !leaksAnswer("ran的过去式", "run") || leaksAnswer("跑", "run") ||
+		leaksAnswer("adj. 好的", "a") || leaksAnswer("brunch", "run") || !leaksAnswer("take off的过去式", "take off") {
+		t.Errorf("leaksAnswer")
+	}
+}
@@ -0,0 +1,33 @@
+package practice
+
+import (
+	"sort"
+
+	idictconfig "github.com/lai323/idict/config"
+	"github.com/lai323/idict/wordset"
+)
+
+// troublesomeWords 返回查询多次的单词，练习时优先出现，单词本不存在时为空
+func troublesomeWords(config *idictconfig.Config) (map[string]bool, error) {
+	name := config.Promotion.WithDefaults().WordSet
+	ws, err := wordset.NewWordSet(name, wordset.WordSetManage{StoragePath: config.StoragePath}.WordSetDir())
+	if err != nil {
+		return nil, err
+	}
+	err = ws.Load()
+	if err != nil {
+		return nil, err
+	}
+	words := map[string]bool{}
+	for w := range ws.Words {
+		words[w] = true
+	}
+	return words, nil
+}
+
+// prioritize 把优先的单词移到前面，其他单词的顺序不变
+func prioritize(words []string, priority map[string]bool) {
+	sort.SliceStable(words, func(i, j int) bool {
+		return priority[words[i]] && !priority[words[j]]
+	})
+}
@@ -0,0 +1,14 @@
+package practice
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrioritize(t *testing.T) {
+	words := []string{"a", "b", "c", "d"}
+	prioritize(words, map[string]bool{"c": true, "d": true})
+	if strings.Join(words, ",") != "c,d,a,b" {
+		t.Errorf("prioritize %v", words)
+	}
+}
@@ -1,13 +1,10 @@
 package practice
 
 import (
-	"encoding/base64"
 	"fmt"
 	"log"
 	"math/rand"
 	"os"
-	"os/exec"
-	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -15,6 +12,7 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lai323/idict/audio"
 	idictconfig "github.com/lai323/idict/config"
 	"github.com/lai323/idict/dict"
 	"github.com/lai323/idict/ui"
@@ -27,39 +25,40 @@ type NextMsg struct {
 	word wordset.Word
 }
 
+type PrefetchMsg struct {
+	result dict.PrefetchResult
+}
+
+// skipMsg 为无法获取的单词
+type skipMsg struct {
+	text string
+	err  error
+}
+
 func init() {
 	rand.Seed(time.Now().Unix())
 }
 
-func getWords(worsetName string, config *idictconfig.Config) (map[string]int, error) {
-	ws, err := wordset.NewWordSet(worsetName, wordset.WordSetManage{StoragePath: config.StoragePath}.WordSetDir())
-	if err != nil {
-		return ws.Words, err
-	}
-	exist, err := ws.Exist()
-	if err != nil {
-		return ws.Words, err
-	}
-	if !exist {
-		return ws.Words, fmt.Errorf("WrodSet %s not exist", worsetName)
-	}
-	err = ws.Load()
-	return ws.Words, nil
+func getWordSet(worsetName string, config *idictconfig.Config) (wordset.WordSet, error) {
+	return wordset.WordSetManage{StoragePath: config.StoragePath}.Load(worsetName)
 }
 
 func initialModel(worsetName string, config *idictconfig.Config) (*PracModel, error) {
 	m := &PracModel{config: config}
 
-	pefile := path.Join(config.StoragePath, "practice_extent.json")
-	pe, err := NewPracExtent(pefile, config.RestudyInterval)
+	pe, err := wordset.NewPracExtent(wordset.PracExtentFile(config.StoragePath), config.RestudyInterval)
 	if err != nil {
 		return m, err
 	}
 
-	words, err := getWords(worsetName, config)
+	ws, err := getWordSet(worsetName, config)
 	if err != nil {
 		return m, err
 	}
+	words := map[string]int{}
+	for w := range ws.Words {
+		words[w] = 0
+	}
 
 	wordNumTotal := len(words)
 	for _, w := range pe.RememberWords() {
@@ -69,15 +68,35 @@ func initialModel(worsetName string, config *idictconfig.Config) (*PracModel, er
 	for w := range words {
 		wordslice = append(wordslice, w)
 	}
+	priority, err := troublesomeWords(config)
+	if err != nil {
+		return m, err
+	}
+	prioritize(wordslice, priority)
+
+	annotations, err := wordset.NewAnnotations(config.StoragePath)
+	if err != nil {
+		return m, err
+	}
 
-	cli, err := dict.NewEuDictClient(*config)
+	cli, err := dict.NewClient(*config)
+	if err != nil {
+		return m, err
+	}
+
+	speaker, err := audio.NewSpeaker(*config)
 	if err != nil {
 		return m, err
 	}
 
 	m.config = config
 	m.cli = cli
+	m.speaker = speaker
+	m.wordset = ws
 	m.Words = wordslice
+	m.priority = priority
+	m.annotations = annotations
+	m.skipped = map[string]bool{}
 	m.wordNumTotal = wordNumTotal
 	m.pracExtent = pe
 	m.textInput = textinput.NewModel()
@@ -104,6 +123,7 @@ type PracModel struct {
 	textInput           textinput.Model
 	viewport            viewport.Model
 	cli                 dict.DictClient
+	speaker             audio.Speaker
 	width               int
 	ready               bool
 	helpmode            ui.HelpModel
@@ -114,14 +134,21 @@ type PracModel struct {
 	successed           bool
 	failed              bool
 	Words               []string
+	wordset             wordset.WordSet
 	wordNumTotal        int
-	pracExtent          pracExtent
+	pracExtent          wordset.PracExtent
 	sencursor           int
 	batchWord           []string
 	batchWordTotal      int
 	batchWordCursor     int
 	currentWord         wordset.Word
 	showAnswer          bool
+	priority            map[string]bool
+	annotations         wordset.Annotations
+	// 无法获取的单词，本次练习中跳过
+	skipped map[string]bool
+	// 显示在底部的错误
+	status string
 }
 
 func (m *PracModel) Init() tea.Cmd {
@@ -131,7 +158,13 @@ func (m *PracModel) Init() tea.Cmd {
 }
 
 func (m *PracModel) genBatchWord() {
-	words := m.pracExtent.ReviewWords()
+	var words []string
+	for _, w := range m.pracExtent.ReviewWords() {
+		if !m.skipped[w] {
+			words = append(words, w)
+		}
+	}
+	prioritize(words, m.priority)
 	if len(words) >= m.config.GroupNum {
 		words = words[:m.config.GroupNum]
 	} else {
@@ -150,39 +183,59 @@ func (m *PracModel) genBatchWord() {
 
 func (m *PracModel) next() []tea.Cmd {
 	cmds := []tea.Cmd{}
+	m.status = ""
 	if len(m.batchWord) == 0 {
 		m.genBatchWord()
+		cmds = append(cmds, m.prefetchCmd())
 	}
 	if len(m.batchWord) == 0 {
+		// 一个单词都没有获取到时保留错误，不直接退出
+		if m.currentWord.Text == "" && len(m.skipped) != 0 {
+			return cmds
+		}
 		cmds = append(cmds, tea.Quit)
 		return cmds
 	}
 
-	voicecmd := m.config.FfplayPath
-	voicearg := m.config.FfplayArgs
 	wordtxet := m.batchWord[0]
 
-	if voicecmd != "" && wordtxet != "" {
+	if m.speaker.Enabled() && wordtxet != "" {
 		cmds = append(cmds, func() tea.Msg {
-			text := "QYN" + base64.StdEncoding.EncodeToString([]byte(wordtxet))
-			url := `https://api.frdic.com/api/v2/speech/speakweb?langid=en&voicename=en_us_female&txt=` + text
-			voicearg = append(voicearg, url)
-			cmd := exec.Command(voicecmd, voicearg...)
-			cmd.Run()
-			return dict.VoiceMsg{}
+			return dict.VoiceMsg{Err: m.speaker.Speak(wordtxet)}
 		})
 	}
 
+	// 练习时获取单词不算查询，不会记录到查询记录中
 	cmds = append(cmds, func() tea.Msg {
-		err, word := m.cli.FetchCache(wordtxet)
+		err, word := m.cli.Cache(wordtxet)
 		if err != nil {
-			panic(err)
+			return skipMsg{text: wordtxet, err: err}
 		}
 		return NextMsg{word: word}
 	})
 	return cmds
 }
 
+// 在后台获取当前这一组和下一组单词，网络中断时也能继续练习
+// 第一个单词由 next 获取，这里不重复获取
+func (m *PracModel) prefetchCmd() tea.Cmd {
+	var words []string
+	if len(m.batchWord) > 1 {
+		words = append(words, m.batchWord[1:]...)
+	}
+	next := m.config.GroupNum
+	if next > len(m.Words) {
+		next = len(m.Words)
+	}
+	words = append(words, m.Words[:next]...)
+	if len(words) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		return PrefetchMsg{result: dict.Prefetch(m.cli, words, m.config.PrefetchWorkers, nil)}
+	}
+}
+
 func (m *PracModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var (
 		cmds []tea.Cmd
@@ -240,11 +293,29 @@ func (m *PracModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.failed = false
 		m.textInput.Focus()
 		m.answertext = ""
-		m.currentWord = msg.word
+		// 导入单词本时附带的翻译和例句
+		m.currentWord = m.wordset.MergeDetail(msg.word)
 		m.sencursor = 0
 		m.showAnswer = false
 		m.batchWordCursor += 1
 		m.textInput.SetValue("")
+	case skipMsg:
+		// 无法获取的单词跳过，继续练习下一个
+		m.skipped[msg.text] = true
+		if len(m.batchWord) != 0 && m.batchWord[0] == msg.text {
+			m.batchWord = m.batchWord[1:]
+			m.batchWordCursor += 1
+		}
+		cmds = append(cmds, m.next()...)
+		m.status = fmt.Sprintf("skipped %s: %s", msg.text, msg.err.Error())
+	case dict.VoiceMsg:
+		if msg.Err != nil && m.status == "" {
+			m.status = "voice: " + msg.Err.Error()
+		}
+	case PrefetchMsg:
+		if failed := len(msg.result.Failed); failed != 0 && m.status == "" {
+			m.status = fmt.Sprintf("prefetch failed for %d of %d words: %s", failed, msg.result.Total, msg.result.FirstError())
+		}
 	}
 
 	// Handle character input and blinks
@@ -261,7 +332,7 @@ func (m *PracModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m *PracModel) answer() {
 	m.answertext = strings.TrimSpace(strings.ToLower(m.textInput.Value()))
-	if m.answertext == strings.ToLower(m.currentWord.Text) {
+	if answerMatch(m.answertext, m.currentWord.Text, m.config.AcceptInflections) {
 		m.successed = true
 		m.textInput.Blur()
 		m.batchWord = m.batchWord[1:]
@@ -305,7 +376,13 @@ func (m *PracModel) View() string {
 }
 
 func (m *PracModel) PracView() string {
-	if !m.ready || m.currentWord.Text == "" {
+	if !m.ready {
+		return "\n  Initalizing..."
+	}
+	if m.currentWord.Text == "" {
+		if m.status != "" {
+			return "\n  " + m.status
+		}
 		return "\n  Initalizing..."
 	}
 	if m.width < 80 {
@@ -315,11 +392,10 @@ func (m *PracModel) PracView() string {
 	wordtrans := ""
 	for _, t := range m.currentWord.Translates {
 		// 有时候翻译中会有这个单词的其他时态，检查一下避免显示答案
-		transtext := strings.ToLower(utils.SpaceMap(t.Mean + t.Part))
-		if strings.Contains(transtext, m.currentWord.Text) {
+		if leaksAnswer(t.Mean, m.currentWord.Text) {
 			continue
 		}
-		if strings.Contains(transtext, "时态") {
+		if strings.Contains(utils.SpaceMap(t.Mean+t.Part), "时态") {
 			continue
 		}
 		wordtrans += fmt.Sprintf("%s %s\n", ui.StyleMean(t.Mean), ui.StylePart(t.Part))
@@ -334,11 +410,11 @@ func (m *PracModel) PracView() string {
 		}
 
 		sen := m.currentWord.Sentences[m.sencursor]
-		senslice := strings.Split(strings.ToLower(sen.Text), strings.ToLower(sen.Word))
-		if len(senslice) > 1 {
-			endstr = strings.Join(senslice[1:], sen.Word)
+		var found bool
+		startstr, endstr, found = splitCloze(sen.Text, m.currentWord.Text)
+		if !found {
+			startstr = startstr + " "
 		}
-		startstr = senslice[0] + " "
 		transtr = sen.Trans
 	} else {
 		startstr = ""
@@ -348,7 +424,7 @@ func (m *PracModel) PracView() string {
 
 	answerstr := ""
 	if m.showAnswer || m.successed {
-		answermodel := ui.TransModel{Word: m.currentWord}
+		answermodel := ui.TransModel{Word: m.currentWord, Annotation: m.annotations.Get(m.currentWord.Text)}
 		answerstr = strings.Join(
 			[]string{
 				ui.StyleMean(m.currentWord.Text), "\n",
@@ -396,7 +472,7 @@ func (m *PracModel) PracView() string {
 		[]string{
 			m.viewport.View(), "\n",
 			infobarstr, "\n",
-			ui.Footer(m.viewport.Width),
+			ui.StatusFooter(m.viewport.Width, m.status),
 		},
 		"",
 	)
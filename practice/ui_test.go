@@ -0,0 +1,71 @@
+package practice
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	idictconfig "github.com/lai323/idict/config"
+	"github.com/lai323/idict/dict"
+	"github.com/lai323/idict/wordset"
+)
+
+type fakeClient struct{}
+
+func (fakeClient) Fetch(text string) (error, wordset.Word)      { return fakeClient{}.Cache(text) }
+func (fakeClient) FetchCache(text string) (error, wordset.Word) { return fakeClient{}.Cache(text) }
+func (fakeClient) Guess(text string) (error, []wordset.GuessWord) {
+	return nil, nil
+}
+func (fakeClient) Cache(text string) (error, wordset.Word) {
+	if text == "broken" {
+		return errors.New("network down"), wordset.Word{}
+	}
+	return nil, wordset.Word{Text: text, Translates: []wordset.Translate{{Mean: text}}}
+}
+
+func TestSkipUnfetchable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pe, err := wordset.NewPracExtent(path.Join(dir, "extent.json"), map[int]int{3: 0, 5: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &PracModel{
+		config:     &idictconfig.Config{GroupNum: 20},
+		cli:        fakeClient{},
+		pracExtent: pe,
+		textInput:  textinput.NewModel(),
+		Words:      []string{"broken", "apple"},
+		skipped:    map[string]bool{},
+	}
+
+	cmds := m.next()
+	// 第一个单词由 next 获取，预先获取时跳过
+	prefetch, ok := cmds[0]().(PrefetchMsg)
+	if !ok || prefetch.result.Total != 1 {
+		t.Errorf("prefetch %+v", prefetch)
+	}
+	msg := cmds[len(cmds)-1]()
+	skip, ok := msg.(skipMsg)
+	if !ok || skip.text != "broken" {
+		t.Fatalf("msg %+v", msg)
+	}
+	m.Update(msg)
+	if !strings.Contains(m.status, "skipped broken: network down") || len(m.batchWord) != 1 || m.batchWord[0] != "apple" {
+		t.Errorf("after skip status %q batch %v", m.status, m.batchWord)
+	}
+
+	m.status = ""
+	m.Update(PrefetchMsg{result: dict.PrefetchResult{Total: 2, Failed: map[string]error{"broken": errors.New("network down")}}})
+	if m.status != "prefetch failed for 1 of 2 words: broken: network down" {
+		t.Errorf("prefetch status %q", m.status)
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	idictconfig "github.com/lai323/idict/config"
+	"github.com/lai323/idict/dict"
+	"github.com/lai323/idict/wordset"
+	"github.com/spf13/cobra"
+)
+
+const DefaultAddr = "127.0.0.1:7766"
+
+type Options struct {
+	Addr *string
+}
+
+// Serve 在本机启动 JSON 接口，收到 SIGINT 或 SIGTERM 时等待正在处理的请求完成后退出
+func Serve(config *idictconfig.Config, options Options) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if config.StoragePath == "" {
+			return errors.New("StoragePath empty")
+		}
+		host, _, err := net.SplitHostPort(*options.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid --addr %s", err.Error())
+		}
+		if !IsLoopback(host) {
+			return fmt.Errorf("--addr must be a localhost address, got %s", host)
+		}
+		cli, err := dict.NewEuDictClient(*config)
+		if err != nil {
+			return err
+		}
+		s := Server{
+			Client: cli,
+			Manage: wordset.WordSetManage{StoragePath: config.StoragePath, RestudyInterval: config.RestudyInterval},
+		}
+
+		ln, err := net.Listen("tcp", *options.Addr)
+		if err != nil {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		fmt.Printf("listening on http://%s\n", ln.Addr())
+		return Run(ctx, ln, s.Handler())
+	}
+}
+
+// Run 处理请求直到 ctx 结束，然后等待正在处理的请求完成后关闭服务
+func Run(ctx context.Context, ln net.Listener, handler http.Handler) error {
+	srv := &http.Server{Handler: handler}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	if err != nil {
+		return fmt.Errorf("shutdown %s", err.Error())
+	}
+	return nil
+}
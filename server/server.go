@@ -0,0 +1,310 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/lai323/idict/dict"
+	"github.com/lai323/idict/wordset"
+)
+
+const defaultSuggestLimit = 10
+
+// Server 为本地的 JSON 接口，使用与命令行相同的客户端和存储
+//
+//	GET    /lookup?word=apple[&record=true] 查询单词，只有 record=true 时和 trans 一样记录查询并加入默认单词本
+//	GET    /lookup?word=apple&cache=false   不使用缓存重新查询，不会加入默认单词本
+//	GET    /suggest?q=app[&limit=10]        联想词
+//	GET    /reverse?q=苹果                  中文对应的英文单词
+//	GET    /wordsets                        所有单词本
+//	GET    /wordsets/<name>                 单词本中的单词
+//	POST   /wordsets/<name>/words           {"words": [...]} 加入单词，单词本不存在时创建
+//	DELETE /wordsets/<name>/words           {"words": [...]} 删除单词
+//	GET    /review[?wordset=name]           需要复习的单词
+//
+// 请求内容需要是 application/json，带有 Origin 的请求只接受本机的页面
+type Server struct {
+	Client dict.DictClient
+	Manage wordset.WordSetManage
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type wordsRequest struct {
+	Words []string `json:"words"`
+}
+
+type wordsResponse struct {
+	Wordset string   `json:"wordset,omitempty"`
+	Words   []string `json:"words"`
+}
+
+type addResponse struct {
+	Added   int      `json:"added"`
+	Invalid []string `json:"invalid,omitempty"`
+}
+
+type removeResponse struct {
+	Removed int      `json:"removed"`
+	Missing []string `json:"missing,omitempty"`
+}
+
+func (s Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lookup", s.lookup)
+	mux.HandleFunc("/suggest", s.suggest)
+	mux.HandleFunc("/reverse", s.reverse)
+	mux.HandleFunc("/wordsets", s.wordsets)
+	mux.HandleFunc("/wordsets/", s.wordset)
+	mux.HandleFunc("/review", s.review)
+	return localOnly(mux)
+}
+
+// localOnly 拒绝 Host 不是本机的请求，避免网页通过 DNS rebinding 访问接口，
+// 也拒绝其他网站的页面发来的请求，浏览器跨站请求时会带上 Origin
+func localOnly(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		}
+		if !IsLoopback(host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %s not allowed", r.Host))
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || !IsLoopback(u.Hostname()) {
+				writeError(w, http.StatusForbidden, fmt.Errorf("origin %s not allowed", origin))
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// IsLoopback 判断主机名是否为本机
+func IsLoopback(host string) bool {
+	host = strings.Trim(host, "[]")
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func allow(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	return false
+}
+
+func (s Server) lookup(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	text := strings.TrimSpace(r.URL.Query().Get("word"))
+	if text == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("word required"))
+		return
+	}
+	// GET 请求默认不修改查询记录和单词本
+	fetch := s.Client.Cache
+	if r.URL.Query().Get("record") == "true" {
+		fetch = s.Client.FetchCache
+	}
+	if r.URL.Query().Get("cache") == "false" {
+		fetch = s.Client.Fetch
+	}
+	err, word := fetch(text)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, word)
+}
+
+func (s Server) suggest(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	limit := defaultSuggestLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", l))
+			return
+		}
+		limit = n
+	}
+	err, words := s.Client.Guess(r.URL.Query().Get("q"))
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	if len(words) > limit {
+		words = words[:limit]
+	}
+	if words == nil {
+		words = []wordset.GuessWord{}
+	}
+	writeJSON(w, http.StatusOK, words)
+}
+
+func (s Server) reverse(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	cli, ok := s.Client.(dict.ReverseClient)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, fmt.Errorf("reverse lookup is not supported"))
+		return
+	}
+	err, words := cli.Reverse(r.URL.Query().Get("q"))
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	if words == nil {
+		words = []wordset.GuessWord{}
+	}
+	writeJSON(w, http.StatusOK, words)
+}
+
+func (s Server) wordsets(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	names, err := s.Manage.Names()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if names == nil {
+		names = []string{}
+	}
+	writeJSON(w, http.StatusOK, names)
+}
+
+// wordset 处理 /wordsets/<name> 和 /wordsets/<name>/words
+func (s Server) wordset(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/wordsets/"), "/")
+	name := parts[0]
+	if !wordset.ValidName(name) || len(parts) > 2 || (len(parts) == 2 && parts[1] != "words") {
+		writeError(w, http.StatusNotFound, fmt.Errorf("not found %s", r.URL.Path))
+		return
+	}
+
+	if len(parts) == 1 {
+		if !allow(w, r, http.MethodGet) {
+			return
+		}
+		if !s.exist(w, name) {
+			return
+		}
+		ws, err := s.Manage.Load(name)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, wordsResponse{Wordset: name, Words: ws.SortedWords()})
+		return
+	}
+
+	if !allow(w, r, http.MethodPost, http.MethodDelete) {
+		return
+	}
+	// 网页的表单和 text/plain 请求不需要预检，只接受 JSON 避免其他网站修改单词本
+	mediatype, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediatype != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("Content-Type must be application/json"))
+		return
+	}
+	var req wordsRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || len(req.Words) == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf(`body must be {"words": [...]}`))
+		return
+	}
+	if r.Method == http.MethodPost {
+		added, invalid, err := s.Manage.AddWords(name, req.Words)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, addResponse{Added: added, Invalid: invalid})
+		return
+	}
+	if !s.exist(w, name) {
+		return
+	}
+	removed, missing, err := s.Manage.RemoveWords(name, req.Words)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, removeResponse{Removed: removed, Missing: missing})
+}
+
+func (s Server) exist(w http.ResponseWriter, name string) bool {
+	ws, err := wordset.NewWordSet(name, s.Manage.WordSetDir())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return false
+	}
+	exist, err := ws.Exist()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return false
+	}
+	if !exist {
+		writeError(w, http.StatusNotFound, fmt.Errorf("wordset %s not exist", name))
+	}
+	return exist
+}
+
+func (s Server) review(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	name := r.URL.Query().Get("wordset")
+	if name != "" && !wordset.ValidName(name) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid wordset %q", name))
+		return
+	}
+	if name != "" && !s.exist(w, name) {
+		return
+	}
+	words, err := s.Manage.Due(name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if words == nil {
+		words = []string{}
+	}
+	writeJSON(w, http.StatusOK, wordsResponse{Wordset: name, Words: words})
+}
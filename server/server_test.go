@@ -0,0 +1,216 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lai323/idict/wordset"
+)
+
+type fakeClient struct {
+	fetched []string
+}
+
+func (c *fakeClient) Fetch(text string) (error, wordset.Word) { return c.Cache(text) }
+func (c *fakeClient) Guess(text string) (error, []wordset.GuessWord) {
+	return nil, []wordset.GuessWord{{Value: text + "le"}, {Value: text + "ly"}}
+}
+func (c *fakeClient) FetchCache(text string) (error, wordset.Word) {
+	c.fetched = append(c.fetched, text)
+	return c.Cache(text)
+}
+func (c *fakeClient) Cache(text string) (error, wordset.Word) {
+	if text == "missing" {
+		return errors.New("not found"), wordset.Word{}
+	}
+	return nil, wordset.Word{Text: text, Translates: []wordset.Translate{{Mean: "苹果"}}}
+}
+
+func do(t *testing.T, ts *httptest.Server, method, path, body string, status int, v interface{}) {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != status {
+		b, _ := ioutil.ReadAll(resp.Body)
+		t.Fatalf("%s %s: status %d, want %d: %s", method, path, resp.StatusCode, status, b)
+	}
+	if v != nil {
+		err = json.NewDecoder(resp.Body).Decode(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	client := &fakeClient{}
+	m := wordset.WordSetManage{StoragePath: dir, RestudyInterval: map[int]int{3: 0, 5: -1}}
+	ts := httptest.NewServer(Server{Client: client, Manage: m}.Handler())
+	defer ts.Close()
+
+	var word wordset.Word
+	do(t, ts, "GET", "/lookup?word=apple", "", 200, &word)
+	if word.Text != "apple" || len(client.fetched) != 0 {
+		t.Errorf("lookup without record %+v %v", word, client.fetched)
+	}
+	do(t, ts, "GET", "/lookup?word=apple&record=true", "", 200, &word)
+	if len(client.fetched) != 1 {
+		t.Errorf("lookup with record fetched %v", client.fetched)
+	}
+	do(t, ts, "GET", "/lookup?word=missing", "", 502, nil)
+	do(t, ts, "GET", "/lookup", "", 400, nil)
+	do(t, ts, "POST", "/lookup?word=apple", "", 405, nil)
+
+	var guesses []wordset.GuessWord
+	do(t, ts, "GET", "/suggest?q=app&limit=1", "", 200, &guesses)
+	if len(guesses) != 1 || guesses[0].Value != "apple" {
+		t.Errorf("suggest %v", guesses)
+	}
+
+	var added addResponse
+	do(t, ts, "POST", "/wordsets/work/words", `{"words": ["idempotent", "apple", "bad1"]}`, 200, &added)
+	if added.Added != 2 || len(added.Invalid) != 1 {
+		t.Errorf("add %+v", added)
+	}
+	var words wordsResponse
+	do(t, ts, "GET", "/wordsets/work", "", 200, &words)
+	if strings.Join(words.Words, ",") != "apple,idempotent" {
+		t.Errorf("wordset %+v", words)
+	}
+	var removed removeResponse
+	do(t, ts, "DELETE", "/wordsets/work/words", `{"words": ["apple", "pear"]}`, 200, &removed)
+	if removed.Removed != 1 || len(removed.Missing) != 1 {
+		t.Errorf("remove %+v", removed)
+	}
+	var names []string
+	do(t, ts, "GET", "/wordsets", "", 200, &names)
+	if len(names) != 1 || names[0] != "work" {
+		t.Errorf("wordsets %v", names)
+	}
+	do(t, ts, "GET", "/wordsets/nope", "", 404, nil)
+	do(t, ts, "DELETE", "/wordsets/nope/words", `{"words": ["a"]}`, 404, nil)
+	do(t, ts, "POST", "/wordsets/work/words", `not json`, 400, nil)
+	do(t, ts, "GET", "/wordsets/work/other", "", 404, nil)
+
+	pe, err := wordset.NewPracExtent(wordset.PracExtentFile(dir), m.RestudyInterval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pe.Reset("idempotent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	do(t, ts, "GET", "/review?wordset=work", "", 200, &words)
+	if strings.Join(words.Words, ",") != "idempotent" {
+		t.Errorf("review %+v", words)
+	}
+	do(t, ts, "GET", "/review?wordset=nope", "", 404, nil)
+}
+
+func TestLocalOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ts := httptest.NewServer(Server{Client: &fakeClient{}, Manage: wordset.WordSetManage{StoragePath: dir}}.Handler())
+	defer ts.Close()
+	send := func(method, path, host, origin, contentType string) int {
+		req, _ := http.NewRequest(method, ts.URL+path, strings.NewReader(`{"words": ["apple"]}`))
+		if host != "" {
+			req.Host = host
+		}
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := send("GET", "/lookup?word=apple", "evil.example.com", "", ""); status != http.StatusForbidden {
+		t.Errorf("foreign host %d", status)
+	}
+	// 其他网站的页面不能修改单词本
+	if status := send("POST", "/wordsets/work/words", "", "https://evil.example.com", "application/json"); status != http.StatusForbidden {
+		t.Errorf("foreign origin %d", status)
+	}
+	if status := send("POST", "/wordsets/work/words", "", "", "text/plain"); status != http.StatusUnsupportedMediaType {
+		t.Errorf("text/plain %d", status)
+	}
+	if status := send("POST", "/wordsets/work/words", "", "http://localhost:3000", "application/json; charset=utf-8"); status != http.StatusOK {
+		t.Errorf("local origin %d", status)
+	}
+}
+
+func TestRunShutdown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 正在处理的请求完成后才退出
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("done"))
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Run(ctx, ln, handler) }()
+
+	respc := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			respc <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		respc <- string(b)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+		t.Fatal("returned before request finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	if body := <-respc; body != "done" {
+		t.Errorf("response %q", body)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("run %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package suggest
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/lai323/idict/wordset"
+)
+
+// Build 由常用单词、内置单词本、用户的单词本和缓存的单词建立索引
+// 缓存中的单词使用第一个翻译作为说明
+func Build(cache wordset.WordCache, wordsetDir string) (*Index, error) {
+	idx := NewIndex()
+	idx.AddFrequency()
+
+	for _, name := range wordset.BuiltinNames() {
+		ws, err := wordset.LoadBuiltin(name)
+		if err != nil {
+			return idx, err
+		}
+		for word := range ws.Words {
+			idx.Add(word, "", ScoreBuiltin)
+		}
+	}
+
+	files, err := ioutil.ReadDir(wordsetDir)
+	if err != nil && !os.IsNotExist(err) {
+		return idx, err
+	}
+	for _, f := range files {
+		if path.Ext(f.Name()) != ".wordset" {
+			continue
+		}
+		ws, err := wordset.NewWordSet(strings.TrimSuffix(f.Name(), ".wordset"), wordsetDir)
+		if err != nil {
+			return idx, err
+		}
+		err = ws.Load()
+		if err != nil {
+			return idx, err
+		}
+		for word := range ws.Words {
+			var label string
+			if d := ws.Details[word]; d != nil {
+				label = Label(d.Translates)
+			}
+			idx.Add(word, label, ScoreWordSet)
+		}
+	}
+
+	err = cache.Walk(func(file string, entry wordset.CacheEntry) error {
+		if len(entry.Word.Translates) == 0 {
+			return nil
+		}
+		idx.Add(entry.Word.Text, Label(entry.Word.Translates), ScoreCached)
+		return nil
+	})
+	return idx, err
+}
+
+// Label 使用第一个翻译作为联想词的说明
+func Label(translates []wordset.Translate) string {
+	if len(translates) == 0 {
+		return ""
+	}
+	t := translates[0]
+	return strings.TrimSpace(t.Part + " " + t.Mean)
+}
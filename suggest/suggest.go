@@ -0,0 +1,203 @@
+package suggest
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/lai323/idict/lemma"
+)
+
+// 各个来源的单词的基础分数，查询过和单词本中的单词排在常用单词之前
+const (
+	ScoreCached  = 3000
+	ScoreWordSet = 2000
+	ScoreBuiltin = 100
+)
+
+type Entry struct {
+	Word  string
+	Label string
+	Score int
+}
+
+// Index 为本地的单词索引，按单词排序，用于前缀查询和模糊匹配
+// 加入单词后在下一次查询时重新排序，Index 不是并发安全的
+type Index struct {
+	entries []Entry
+	byWord  map[string]int
+	sorted  bool
+}
+
+func NewIndex() *Index {
+	return &Index{byWord: map[string]int{}}
+}
+
+// Add 加入单词，已存在时分数相加，已有的说明不会被覆盖
+func (idx *Index) Add(word, label string, score int) {
+	word = strings.ToLower(strings.Join(strings.Fields(word), " "))
+	if word == "" {
+		return
+	}
+	if i, ok := idx.byWord[word]; ok {
+		idx.entries[i].Score += score
+		if idx.entries[i].Label == "" {
+			idx.entries[i].Label = label
+		}
+		return
+	}
+	idx.byWord[word] = len(idx.entries)
+	idx.entries = append(idx.entries, Entry{Word: word, Label: label, Score: score})
+	idx.sorted = false
+}
+
+// AddFrequency 加入内置的常用单词，越常用分数越高
+func (idx *Index) AddFrequency() {
+	words := lemma.CommonWords()
+	for i, w := range words {
+		idx.Add(w, "", len(words)-i)
+	}
+}
+
+func (idx *Index) Len() int {
+	return len(idx.entries)
+}
+
+func (idx *Index) sort() {
+	if idx.sorted {
+		return
+	}
+	sort.Slice(idx.entries, func(i, j int) bool { return idx.entries[i].Word < idx.entries[j].Word })
+	for i, e := range idx.entries {
+		idx.byWord[e.Word] = i
+	}
+	idx.sorted = true
+}
+
+// Suggest 返回最多 limit 个以 text 开头的单词，不够时加入拼写相近的单词
+// 前缀匹配的按分数排序，模糊匹配的按编辑距离和分数排序
+func (idx *Index) Suggest(text string, limit int) []Entry {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	if text == "" || limit <= 0 {
+		return nil
+	}
+	idx.sort()
+
+	start := sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].Word >= text })
+	var prefix []Entry
+	for i := start; i < len(idx.entries) && strings.HasPrefix(idx.entries[i].Word, text); i++ {
+		prefix = append(prefix, idx.entries[i])
+	}
+	sort.SliceStable(prefix, func(i, j int) bool { return better(prefix[i], prefix[j]) })
+	if len(prefix) >= limit {
+		return prefix[:limit]
+	}
+
+	max := maxDistance(text)
+	if max == 0 {
+		return prefix
+	}
+	type fuzzy struct {
+		Entry
+		distance int
+	}
+	var matches []fuzzy
+	for _, e := range idx.entries {
+		if strings.HasPrefix(e.Word, text) {
+			continue
+		}
+		if d := prefixDistance(text, e.Word, max); d <= max {
+			matches = append(matches, fuzzy{e, d})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return better(matches[i].Entry, matches[j].Entry)
+	})
+	for _, m := range matches {
+		if len(prefix) >= limit {
+			break
+		}
+		prefix = append(prefix, m.Entry)
+	}
+	return prefix
+}
+
+func better(a, b Entry) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if len(a.Word) != len(b.Word) {
+		return len(a.Word) < len(b.Word)
+	}
+	return a.Word < b.Word
+}
+
+// 输入越长允许的拼写错误越多，太短时不做模糊匹配
+func maxDistance(text string) int {
+	switch n := len([]rune(text)); {
+	case n <= 2:
+		return 0
+	case n <= 5:
+		return 1
+	}
+	return 2
+}
+
+// prefixDistance 返回 text 与 word 的某个前缀之间最小的编辑距离，相邻字母交换算一次编辑
+// 距离超过 max 时提前返回 max+1
+func prefixDistance(text, word string, max int) int {
+	a, b := []rune(text), []rune(word)
+	if len(b) > len(a)+max {
+		b = b[:len(a)+max]
+	}
+	if len(b) < len(a)-max {
+		return max + 1
+	}
+	// d[i][j] 为 a[:i] 与 b[:j] 之间的距离
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		rowMin := d[i][0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+			if d[i][j] < rowMin {
+				rowMin = d[i][j]
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+	}
+	best := max + 1
+	for j := 0; j <= len(b); j++ {
+		if d[len(a)][j] < best {
+			best = d[len(a)][j]
+		}
+	}
+	return best
+}
+
+func min(v ...int) int {
+	m := v[0]
+	for _, x := range v[1:] {
+		if x < m {
+			m = x
+		}
+	}
+	return m
+}
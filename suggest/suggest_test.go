@@ -0,0 +1,86 @@
+package suggest
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/lai323/idict/wordset"
+)
+
+func words(entries []Entry) []string {
+	var ws []string
+	for _, e := range entries {
+		ws = append(ws, e.Word)
+	}
+	return ws
+}
+
+func TestSuggest(t *testing.T) {
+	idx := NewIndex()
+	idx.AddFrequency()
+	idx.Add("receive", "v. 收到", ScoreCached)
+	idx.Add("recursion", "", ScoreWordSet)
+
+	got := words(idx.Suggest("rec", 3))
+	if len(got) != 3 || got[0] != "receive" || got[1] != "recursion" {
+		t.Errorf("prefix %v", got)
+	}
+	for _, c := range []struct{ text, want string }{
+		{"recieve", "receive"},
+		{"teh", "the"},
+		{"becuase", "because"},
+		{"Recur", "recursion"},
+	} {
+		found := false
+		for _, w := range words(idx.Suggest(c.text, 10)) {
+			found = found || w == c.want
+		}
+		if !found {
+			t.Errorf("Suggest(%q) %v, want %s", c.text, words(idx.Suggest(c.text, 10)), c.want)
+		}
+	}
+	if got := idx.Suggest("zq", 10); len(got) != 0 {
+		t.Errorf("short fuzzy %v", words(got))
+	}
+	if got := idx.Suggest("receive", 1); got[0].Label != "v. 收到" {
+		t.Errorf("label %v", got)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache, err := wordset.NewWordCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cache.Set(wordset.Word{Text: "quixotic", Translates: []wordset.Translate{{Part: "adj.", Mean: "不切实际的"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := wordset.WordSetManage{StoragePath: dir}
+	err = m.Add("work", []string{"idempotent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := Build(cache, m.WordSetDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := idx.Suggest("quix", 1); len(got) != 1 || got[0].Label != "adj. 不切实际的" {
+		t.Errorf("cached %v", got)
+	}
+	if got := words(idx.Suggest("idemp", 1)); len(got) != 1 || got[0] != "idempotent" {
+		t.Errorf("wordset %v", got)
+	}
+	// 查询过的单词排在常用单词之前
+	if got := words(idx.Suggest("qu", 1)); got[0] != "quixotic" {
+		t.Errorf("score %v", got)
+	}
+}
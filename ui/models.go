@@ -12,7 +12,30 @@ type WordMsg struct {
 }
 
 type TransModel struct {
-	Word wordset.Word
+	Word       wordset.Word
+	Annotation wordset.Annotation
+}
+
+// annotationView 显示自己加的收藏、标签和笔记
+func (m TransModel) annotationView() string {
+	var text []string
+	var line []string
+	if m.Annotation.Star {
+		line = append(line, StyleStar("★"))
+	}
+	for _, t := range m.Annotation.Tags {
+		line = append(line, StyleTag("#"+t))
+	}
+	if len(line) != 0 {
+		text = append(text, strings.Join(line, " "))
+	}
+	if m.Annotation.Note != "" {
+		text = append(text, StyleNote("Note: "+m.Annotation.Note))
+	}
+	if len(text) == 0 {
+		return ""
+	}
+	return "\n" + strings.Join(text, "\n") + "\n"
 }
 
 func (m TransModel) View() string {
@@ -44,7 +67,7 @@ func (m TransModel) View() string {
 	}
 
 	return strings.Join([]string{
-		pronounce,
+		m.annotationView() + pronounce,
 		transtext,
 		phrasetext,
 		sentencetext,
@@ -18,6 +18,9 @@ var (
 	StyleSuccess         = NewStyle("#67f86f", "", true, false)
 	Stylefail            = NewStyle("#fd6f59", "", true, false)
 	StyleWordCount       = NewStyle("#aeaeae", "", true, false)
+	StyleStar            = NewStyle("#ffc27d", "", true, false)
+	StyleTag             = NewStyle("#66C2CD", "", false, false)
+	StyleNote            = NewStyle("#67f86f", "", false, true)
 )
 
 const (
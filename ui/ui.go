@@ -115,13 +115,35 @@ func JoinLines(texts ...string) string {
 }
 
 func Footer(width int) string {
+	return StatusFooter(width, "")
+}
 
+// StatusFooter 与 Footer 相同，status 不为空时代替帮助显示，例如出错的原因
+func StatusFooter(width int, status string) string {
+	status = strings.Join(strings.Fields(status), " ")
 	if width < 80 {
+		if status != "" {
+			return Stylefail(Truncate(status, width))
+		}
 		return StyleLogo(" idict ")
 	}
 
 	t := time.Now()
 	tstr := fmt.Sprintf("%s %02d:%02d:%02d", t.Weekday().String(), t.Hour(), t.Minute(), t.Second())
+	if status != "" {
+		statusWidth := width - len(tstr) - 1
+		return Line(
+			width,
+			Cell{
+				Width: statusWidth,
+				Text:  Stylefail(Truncate(status, statusWidth)),
+			},
+			Cell{
+				Text:  StyleHelp(tstr),
+				Align: RightAlign,
+			},
+		)
+	}
 
 	return Line(
 		width,
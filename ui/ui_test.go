@@ -2,8 +2,10 @@ package ui
 
 import (
 	"fmt"
-	// "strings"
+	"strings"
 	"testing"
+
+	"github.com/lai323/idict/wordset"
 )
 
 func TestLine(t *testing.T) {
@@ -35,3 +37,17 @@ func TestLine(t *testing.T) {
 	// text := "n. 阿帕奇人(Apache的复数形式 ,美洲印第安人s的一..."
 	// fmt.Println(string([]rune("abbr. 美国政治和社会科学研究院(American ..."[:40])))
 }
+
+func TestTransModelAnnotation(t *testing.T) {
+	m := TransModel{
+		Word:       wordset.Word{Text: "idempotent"},
+		Annotation: wordset.Annotation{Star: true, Tags: []string{"work"}, Note: "used in our API docs"},
+	}
+	view := m.View()
+	if !strings.Contains(view, "#work") || !strings.Contains(view, "Note: used in our API docs") {
+		t.Errorf("annotation not shown: %q", view)
+	}
+	if strings.Contains(TransModel{Word: m.Word}.View(), "Note") {
+		t.Errorf("empty annotation shown")
+	}
+}
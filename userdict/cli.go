@@ -0,0 +1,156 @@
+package userdict
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	idictconfig "github.com/lai323/idict/config"
+	"github.com/lai323/idict/wordset"
+	"github.com/spf13/cobra"
+)
+
+// 第一次编辑时的文件内容
+const template = `# Your own dictionary entries, merged into lookups and practice.
+# Translations, phonetics, phrases and sentences here come before the dictionary's.
+# With replace: true only the translations here are used and the word is not looked up online.
+#
+# idempotent:
+#   replace: true
+#   us: /ˌaɪdəmˈpoʊtənt/
+#   translates:
+#   - part: adj.
+#     mean: 幂等的（多次执行结果相同）
+#   sentences:
+#   - text: PUT requests should be idempotent.
+#     trans: PUT 请求应该是幂等的。
+`
+
+func storage(config *idictconfig.Config) (string, error) {
+	if config.StoragePath == "" {
+		return "", errors.New("StoragePath empty")
+	}
+	return config.StoragePath, nil
+}
+
+// Editor 返回编辑器命令，依次使用 $VISUAL $EDITOR，都没有设置时为 vi
+func Editor() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) != 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+// Edit 使用编辑器编辑自己的词典，编辑的是临时文件，格式正确时才会保存
+func Edit(config *idictconfig.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		storagePath, err := storage(config)
+		if err != nil {
+			return err
+		}
+		return EditFile(wordset.UserDictFile(storagePath), Editor())
+	}
+}
+
+func EditFile(file string, editor []string) error {
+	content, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		content, err = []byte(template), nil
+	}
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile("", "idict-userdict-*.yaml")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	c := exec.Command(editor[0], append(editor[1:], tmp.Name())...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err = c.Run()
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("run editor %s %s", editor[0], err.Error())
+	}
+
+	edited, err := ioutil.ReadFile(tmp.Name())
+	if err != nil {
+		return err
+	}
+	_, err = wordset.ParseUserDict(edited)
+	if err != nil {
+		return fmt.Errorf("user dictionary not saved, your edit is kept in %s: %s", tmp.Name(), err.Error())
+	}
+	os.Remove(tmp.Name())
+	if string(edited) == string(content) {
+		fmt.Println("no changes")
+		return nil
+	}
+	return ioutil.WriteFile(file, edited, 0644)
+}
+
+// Import 从 yaml 文件导入词条，已有的词条会被覆盖
+func Import(config *idictconfig.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		storagePath, err := storage(config)
+		if err != nil {
+			return err
+		}
+		d, err := wordset.NewUserDict(storagePath)
+		if err != nil {
+			return err
+		}
+		for _, file := range args {
+			filebyte, err := ioutil.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("read file %s %s", file, err.Error())
+			}
+			words, err := wordset.ParseUserDict(filebyte)
+			if err != nil {
+				return fmt.Errorf("parse %s %s", file, err.Error())
+			}
+			added, replaced, err := d.Import(words)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s: added %d, replaced %d\n", file, added, replaced)
+		}
+		return nil
+	}
+}
+
+// List 列出自己的词典中的单词和第一个翻译
+func List(config *idictconfig.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		storagePath, err := storage(config)
+		if err != nil {
+			return err
+		}
+		d, err := wordset.NewUserDict(storagePath)
+		if err != nil {
+			return err
+		}
+		for _, w := range d.Words() {
+			e, _ := d.Get(w)
+			mean := ""
+			if len(e.Translates) != 0 {
+				mean = strings.TrimSpace(e.Translates[0].Part + " " + e.Translates[0].Mean)
+			}
+			fmt.Printf("%s\t%s\n", w, mean)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,48 @@
+package userdict
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestEditFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := path.Join(dir, "userdict.yaml")
+	edited := path.Join(dir, "edited.yaml")
+
+	// 编辑器为 cp，把准备好的内容复制到临时文件
+	err = ioutil.WriteFile(edited, []byte("idempotent:\n  translates:\n  - mean: 幂等的\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = EditFile(file, []string{"cp", edited})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil || !strings.Contains(string(content), "幂等的") {
+		t.Errorf("saved %q %v", content, err)
+	}
+
+	err = ioutil.WriteFile(edited, []byte("idempotent:\n  mean: bad\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = EditFile(file, []string{"cp", edited})
+	if err == nil || !strings.Contains(err.Error(), "not saved") {
+		t.Fatalf("invalid edit %v", err)
+	}
+	kept := strings.SplitN(strings.SplitN(err.Error(), "kept in ", 2)[1], ": ", 2)[0]
+	os.Remove(kept)
+	content, _ = ioutil.ReadFile(file)
+	if !strings.Contains(string(content), "幂等的") {
+		t.Errorf("invalid edit overwrote file %q", content)
+	}
+}
@@ -0,0 +1,270 @@
+package wordset
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lai323/idict/lemma"
+)
+
+// 单词本的集合运算，结果为没有名称和存储目录的新单词本，单词的附加信息会被合并
+// 练习进度按单词记录，所有单词本共用，所以运算不需要处理进度
+
+func newWordSet() WordSet {
+	return WordSet{Words: map[string]int{}, Details: map[string]*WordDetail{}}
+}
+
+func (ws WordSet) detail(word string) WordDetail {
+	if d, ok := ws.Details[word]; ok {
+		return *d
+	}
+	return WordDetail{}
+}
+
+// Union 返回出现在任意一个单词本中的单词
+func (ws WordSet) Union(others ...WordSet) WordSet {
+	result := newWordSet()
+	for _, s := range append([]WordSet{ws}, others...) {
+		for word := range s.Words {
+			result.Put(word, s.detail(word))
+		}
+	}
+	return result
+}
+
+// Intersect 返回在所有单词本中都出现的单词
+func (ws WordSet) Intersect(others ...WordSet) WordSet {
+	result := newWordSet()
+	for word := range ws.Words {
+		in := true
+		for _, s := range others {
+			if _, ok := s.Words[word]; !ok {
+				in = false
+				break
+			}
+		}
+		if !in {
+			continue
+		}
+		result.Put(word, ws.detail(word))
+		for _, s := range others {
+			result.Put(word, s.detail(word))
+		}
+	}
+	return result
+}
+
+// Subtract 返回不在任何一个 others 中的单词
+func (ws WordSet) Subtract(others ...WordSet) WordSet {
+	result := newWordSet()
+	for word := range ws.Words {
+		in := false
+		for _, s := range others {
+			if _, ok := s.Words[word]; ok {
+				in = true
+				break
+			}
+		}
+		if !in {
+			result.Put(word, ws.detail(word))
+		}
+	}
+	return result
+}
+
+// Occurrence 为单词在某个单词本中的一次出现
+type Occurrence struct {
+	Set  string
+	Word string
+}
+
+// DuplicatesBy 按 key 把单词分组，返回出现不止一次的组，组内按 sets 的顺序
+// key 为 nil 时按单词本身分组
+func DuplicatesBy(sets []WordSet, key func(string) string) map[string][]Occurrence {
+	groups := map[string][]Occurrence{}
+	for _, s := range sets {
+		for _, word := range s.SortedWords() {
+			k := word
+			if key != nil {
+				k = key(word)
+			}
+			groups[k] = append(groups[k], Occurrence{Set: s.Name, Word: word})
+		}
+	}
+	for k, occ := range groups {
+		if len(occ) < 2 {
+			delete(groups, k)
+		}
+	}
+	return groups
+}
+
+// Duplicates 返回出现在多个单词本中的单词及包含它的单词本名称，名称按 sets 的顺序
+func Duplicates(sets []WordSet) map[string][]string {
+	dups := map[string][]string{}
+	for word, occ := range DuplicatesBy(sets, nil) {
+		for _, o := range occ {
+			dups[word] = append(dups[word], o.Set)
+		}
+	}
+	return dups
+}
+
+func (m WordSetManage) loadAll(names []string) ([]WordSet, error) {
+	var sets []WordSet
+	for _, name := range names {
+		ws, err := m.Load(name)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, ws)
+	}
+	return sets, nil
+}
+
+// saveResult 把运算结果保存为新的单词本 dst
+func (m WordSetManage) saveResult(dst, op string, srcs []string, result WordSet) error {
+	ws, err := m.create(dst)
+	if err != nil {
+		return err
+	}
+	result.Name = ws.Name
+	result.StorageDir = ws.StorageDir
+	result.Meta.Source = op + " " + strings.Join(srcs, " ")
+	err = result.Save(false)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created %s with %d words\n", dst, len(result.Words))
+	return nil
+}
+
+// Intersect 把 srcs 中都有的单词保存为新的单词本 dst
+func (m WordSetManage) Intersect(dst string, srcs []string) error {
+	if len(srcs) < 2 {
+		return errors.New("at least two word sets are required to intersect")
+	}
+	sets, err := m.loadAll(srcs)
+	if err != nil {
+		return err
+	}
+	return m.saveResult(dst, "intersect", srcs, sets[0].Intersect(sets[1:]...))
+}
+
+// Subtract 把在 src 中但不在 others 中的单词保存为新的单词本 dst
+func (m WordSetManage) Subtract(dst, src string, others []string) error {
+	if len(others) == 0 {
+		return errors.New("no word set to subtract")
+	}
+	sets, err := m.loadAll(append([]string{src}, others...))
+	if err != nil {
+		return err
+	}
+	return m.saveResult(dst, "subtract", append([]string{src}, others...), sets[0].Subtract(sets[1:]...))
+}
+
+// Dedupe 列出出现在多个单词本中的单词，names 为空时检查所有单词本
+// byLemma 时同一个单词的不同变形也算重复，包括同一个单词本中的，例如 run 和 running
+// remove 时单词只保留在 names 中第一个包含它的单词本，其他的被删除，按原形去重时保留原形
+func (m WordSetManage) Dedupe(names []string, remove, byLemma bool) error {
+	if len(names) == 0 {
+		if remove {
+			return errors.New("word sets must be given in order of priority to remove duplicates")
+		}
+		var err error
+		names, err = m.Names()
+		if err != nil {
+			return err
+		}
+	}
+	sets, err := m.loadAll(names)
+	if err != nil {
+		return err
+	}
+	var key func(string) string
+	if byLemma {
+		// 只还原为单词本中的单词或常用单词，例如 thing 不会变为 the
+		known := map[string]bool{}
+		for _, s := range sets {
+			for w := range s.Words {
+				known[w] = true
+			}
+		}
+		key = lemma.Lemmatizer{Known: func(w string) bool { return known[w] }}.Lemma
+	}
+	dups := DuplicatesBy(sets, key)
+	keys := make([]string, 0, len(dups))
+	for k := range dups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "WORD\tSETS")
+	for _, k := range keys {
+		var in []string
+		for _, o := range dups[k] {
+			if o.Word == k {
+				in = append(in, o.Set)
+			} else {
+				in = append(in, o.Set+":"+o.Word)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\n", k, strings.Join(in, " "))
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d words in more than one place\n", len(keys))
+	if !remove {
+		return nil
+	}
+
+	byName := map[string]*WordSet{}
+	for i := range sets {
+		byName[sets[i].Name] = &sets[i]
+	}
+	changed := map[string]int{}
+	for _, k := range keys {
+		occ := dups[k]
+		keep := byName[occ[0].Set]
+		// 有原形时保留原形
+		keepWord := occ[0].Word
+		for _, o := range occ {
+			if o.Word == k {
+				keepWord = k
+			}
+		}
+		for _, o := range occ {
+			ws := byName[o.Set]
+			if ws == keep && o.Word == keepWord {
+				continue
+			}
+			if ws == keep {
+				keep.Put(keepWord, ws.detail(o.Word))
+			}
+			delete(ws.Words, o.Word)
+			delete(ws.Details, o.Word)
+			changed[ws.Name]++
+		}
+		if _, ok := keep.Words[keepWord]; !ok {
+			keep.Put(keepWord, WordDetail{})
+		}
+	}
+	for _, ws := range sets {
+		if changed[ws.Name] == 0 {
+			continue
+		}
+		err = byName[ws.Name].Save(true)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("removed %d words from %s\n", changed[ws.Name], ws.Name)
+	}
+	return nil
+}
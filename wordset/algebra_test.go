@@ -0,0 +1,145 @@
+package wordset
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func testWordSet(name string, words ...string) WordSet {
+	ws := WordSet{Name: name, Words: map[string]int{}, Details: map[string]*WordDetail{}}
+	for _, w := range words {
+		ws.Put(w, WordDetail{})
+	}
+	return ws
+}
+
+func TestWordSetAlgebra(t *testing.T) {
+	a := testWordSet("a", "apple", "banana", "take off")
+	a.Put("apple", WordDetail{Note: "from a"})
+	b := testWordSet("b", "banana", "cherry", "take off")
+	b.Put("banana", WordDetail{Translates: []Translate{{Mean: "香蕉"}}})
+	c := testWordSet("c", "banana", "date")
+
+	union := a.Union(b, c)
+	if !reflect.DeepEqual(union.SortedWords(), []string{"apple", "banana", "cherry", "date", "take off"}) {
+		t.Errorf("Union: %v", union.SortedWords())
+	}
+	if union.Note("apple") != "from a" || len(union.Details["banana"].Translates) != 1 {
+		t.Errorf("Union details: %+v", union.Details)
+	}
+
+	inter := a.Intersect(b)
+	if !reflect.DeepEqual(inter.SortedWords(), []string{"banana", "take off"}) {
+		t.Errorf("Intersect: %v", inter.SortedWords())
+	}
+	if len(inter.Details["banana"].Translates) != 1 {
+		t.Errorf("Intersect details: %+v", inter.Details)
+	}
+	if got := a.Intersect(b, c).SortedWords(); !reflect.DeepEqual(got, []string{"banana"}) {
+		t.Errorf("Intersect three: %v", got)
+	}
+
+	sub := a.Subtract(b, c)
+	if !reflect.DeepEqual(sub.SortedWords(), []string{"apple"}) || sub.Note("apple") != "from a" {
+		t.Errorf("Subtract: %v %+v", sub.SortedWords(), sub.Details)
+	}
+	if len(a.Subtract().Words) != 3 {
+		t.Errorf("Subtract nothing: %v", a.Subtract().Words)
+	}
+
+	// 运算不修改原来的单词本
+	if len(a.Words) != 3 || a.Details["apple"].Note != "from a" || len(b.Words) != 3 {
+		t.Errorf("operands modified: %v %v", a.Words, b.Words)
+	}
+
+	dups := Duplicates([]WordSet{a, b, c})
+	want := map[string][]string{"banana": {"a", "b", "c"}, "take off": {"a", "b"}}
+	if !reflect.DeepEqual(dups, want) {
+		t.Errorf("Duplicates: %v", dups)
+	}
+}
+
+func TestManageAlgebra(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	m := WordSetManage{StoragePath: dir}
+
+	for name, words := range map[string][]string{
+		"a": {"apple", "banana", "cherry"},
+		"b": {"banana", "cherry", "date"},
+	} {
+		if err := m.Add(name, words); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Intersect("ab", []string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Subtract("a-b", "a", []string{"b"}); err != nil {
+		t.Fatal(err)
+	}
+	if m.Intersect("a", []string{"a", "b"}) == nil {
+		t.Errorf("Intersect overwrote existing word set")
+	}
+	for name, want := range map[string][]string{
+		"ab":  {"banana", "cherry"},
+		"a-b": {"apple"},
+	} {
+		ws, err := m.Load(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ws.SortedWords(), want) {
+			t.Errorf("%s: %v", name, ws.SortedWords())
+		}
+	}
+
+	if m.Dedupe(nil, true, false) == nil {
+		t.Errorf("Dedupe removed without order of priority")
+	}
+	if err := m.Dedupe([]string{"b", "a"}, true, false); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := m.Load("a")
+	b, _ := m.Load("b")
+	if !reflect.DeepEqual(a.SortedWords(), []string{"apple"}) || len(b.Words) != 3 {
+		t.Errorf("Dedupe: a %v b %v", a.SortedWords(), b.SortedWords())
+	}
+
+	// 按原形去重，running 和 ran 都保留为 run
+	if err := m.Add("c", []string{"running", "ran", "apples"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Add("d", []string{"run", "apple"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Dedupe([]string{"c", "d"}, true, true); err != nil {
+		t.Fatal(err)
+	}
+	c, _ := m.Load("c")
+	d, _ := m.Load("d")
+	if !reflect.DeepEqual(c.SortedWords(), []string{"apple", "run"}) || len(d.Words) != 0 {
+		t.Errorf("Dedupe by lemma: c %v d %v", c.SortedWords(), d.SortedWords())
+	}
+
+	// 不是变形的单词不会被合并
+	if err := m.Add("e", []string{"thing", "evening"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Add("f", []string{"the", "even"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Dedupe([]string{"e", "f"}, true, true); err != nil {
+		t.Fatal(err)
+	}
+	e, _ := m.Load("e")
+	f, _ := m.Load("f")
+	if !reflect.DeepEqual(e.SortedWords(), []string{"evening", "thing"}) || len(f.Words) != 2 {
+		t.Errorf("Dedupe by lemma: e %v f %v", e.SortedWords(), f.SortedWords())
+	}
+}
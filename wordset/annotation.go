@@ -0,0 +1,143 @@
+package wordset
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Annotation 为自己给单词加的收藏、标签和笔记，与缓存和单词本分开保存
+type Annotation struct {
+	Star    bool      `yaml:"star,omitempty"`
+	Tags    []string  `yaml:"tags,omitempty"`
+	Note    string    `yaml:"note,omitempty"`
+	Updated time.Time `yaml:"updated"`
+}
+
+func (a Annotation) Empty() bool {
+	return !a.Star && len(a.Tags) == 0 && a.Note == ""
+}
+
+func (a Annotation) HasTag(tag string) bool {
+	for _, t := range a.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTags 解析以逗号或空白分隔的标签，去掉重复的标签
+func ParseTags(text string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, t := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '，' || r == ' ' || r == '\t' }) {
+		t = strings.ToLower(t)
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+	return tags
+}
+
+// Annotations 保存在 StoragePath/annotations.yaml，可以直接编辑
+type Annotations struct {
+	file  string
+	words map[string]Annotation
+}
+
+func AnnotationsFile(storagePath string) string {
+	return path.Join(storagePath, "annotations.yaml")
+}
+
+func NewAnnotations(storagePath string) (Annotations, error) {
+	a := Annotations{file: AnnotationsFile(storagePath), words: map[string]Annotation{}}
+	return a, a.load()
+}
+
+// load 重新读取文件，其他进程可能在之后修改了注释
+func (a Annotations) load() error {
+	var words map[string]Annotation
+	filebyte, err := ioutil.ReadFile(a.file)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Annotations read %s", err.Error())
+	}
+	if err == nil {
+		err = yaml.Unmarshal(filebyte, &words)
+		if err != nil {
+			return fmt.Errorf("Annotations decode %s %s", a.file, err.Error())
+		}
+	}
+	for w := range a.words {
+		delete(a.words, w)
+	}
+	for w, annotation := range words {
+		a.words[w] = annotation
+	}
+	return nil
+}
+
+func (a Annotations) Get(word string) Annotation {
+	return a.words[NormalizeWord(word)]
+}
+
+// Set 保存单词的注释，注释为空时删除，保存前重新读取文件，不会覆盖其他进程保存的注释
+func (a Annotations) Set(word string, annotation Annotation) error {
+	word = NormalizeWord(word)
+	if word == "" {
+		return nil
+	}
+	err := a.load()
+	if err != nil {
+		return err
+	}
+	if annotation.Empty() {
+		delete(a.words, word)
+	} else {
+		annotation.Tags = ParseTags(strings.Join(annotation.Tags, ","))
+		annotation.Updated = time.Now()
+		a.words[word] = annotation
+	}
+	return a.save()
+}
+
+func (a Annotations) save() error {
+	filebyte, err := yaml.Marshal(a.words)
+	if err != nil {
+		return fmt.Errorf("Annotations encode %s", err.Error())
+	}
+	err = writeFileAtomic(a.file, filebyte)
+	if err != nil {
+		return fmt.Errorf("Annotations write %s %s", a.file, err.Error())
+	}
+	return nil
+}
+
+// Search 按字母顺序返回单词或笔记匹配 grep，并且有 tag 标签的单词
+// grep 为 nil、tag 为空时不过滤，starred 时只返回收藏的单词
+func (a Annotations) Search(grep *regexp.Regexp, tag string, starred bool) []string {
+	var words []string
+	for word, annotation := range a.words {
+		if starred && !annotation.Star {
+			continue
+		}
+		if tag != "" && !annotation.HasTag(tag) {
+			continue
+		}
+		if grep != nil && !grep.MatchString(word) && !grep.MatchString(annotation.Note) {
+			continue
+		}
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
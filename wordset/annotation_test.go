@@ -0,0 +1,111 @@
+package wordset
+
+import (
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAnnotations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	a, err := NewAnnotations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = a.Set("Idempotent", Annotation{Note: "used in our API docs", Tags: []string{"Work", "api", "work"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = a.Set("take  off", Annotation{Star: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = a.Set("apple", Annotation{Star: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 清空注释时删除
+	err = a.Set("apple", Annotation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err = NewAnnotations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Get("idempotent"); got.Note != "used in our API docs" || strings.Join(got.Tags, ",") != "work,api" {
+		t.Errorf("get %+v", got)
+	}
+	if got := strings.Join(a.Search(nil, "", false), ","); got != "idempotent,take off" {
+		t.Errorf("all %s", got)
+	}
+	if got := a.Search(nil, "", true); len(got) != 1 || got[0] != "take off" {
+		t.Errorf("starred %v", got)
+	}
+	if got := a.Search(regexp.MustCompile("API"), "WORK", false); len(got) != 1 || got[0] != "idempotent" {
+		t.Errorf("grep and tag %v", got)
+	}
+}
+
+func TestAnnotationsOtherProcess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	a, err := NewAnnotations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewAnnotations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = a.Set("apple", Annotation{Note: "from a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// b 在 a 保存之前读取，保存时不能覆盖 a 的注释
+	err = b.Set("pear", Annotation{Note: "from b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Get("apple").Note != "from a" {
+		t.Errorf("b apple %+v", b.Get("apple"))
+	}
+	err = b.Set("apple", Annotation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewAnnotations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Get("pear").Note != "from b" || !c.Get("apple").Empty() {
+		t.Errorf("reloaded %+v %+v", c.Get("pear"), c.Get("apple"))
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), ".tmp-") {
+			t.Errorf("temp file left %s", f.Name())
+		}
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	if got := strings.Join(ParseTags(" work, API，work  cs "), ","); got != "work,api,cs" {
+		t.Errorf("ParseTags %s", got)
+	}
+}
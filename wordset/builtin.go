@@ -0,0 +1,101 @@
+package wordset
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// 内置的单词本，格式和用户的单词本相同，revision 在增加单词时递增
+//
+//go:embed builtin/*.wordset
+var builtinFS embed.FS
+
+// BuiltinNames 返回所有内置单词本的名称
+func BuiltinNames() []string {
+	entries, err := builtinFS.ReadDir("builtin")
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".wordset"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadBuiltin 读取内置单词本，返回的单词本没有存储目录，不能直接保存
+func LoadBuiltin(name string) (WordSet, error) {
+	ws := WordSet{Name: name, Words: map[string]int{}, Details: map[string]*WordDetail{}}
+	filebyte, err := builtinFS.ReadFile(path.Join("builtin", name+".wordset"))
+	if err != nil {
+		return ws, fmt.Errorf("unknown builtin word set %s, available: %s", name, strings.Join(BuiltinNames(), ", "))
+	}
+	err = decodeWordSet(&ws, filebyte)
+	if err != nil {
+		return ws, fmt.Errorf("builtin word set %s %s", name, err.Error())
+	}
+	ws.Meta.Builtin = name
+	return ws, nil
+}
+
+// Install 安装内置单词本，as 为空时使用内置单词本的名称
+// 已安装时只加入新版本中增加的单词，用户加入的单词和练习进度不受影响
+func (m WordSetManage) Install(name, as string) error {
+	builtin, err := LoadBuiltin(name)
+	if err != nil {
+		return err
+	}
+	if as == "" {
+		as = name
+	}
+	ws, err := m.loadOrCreate(as)
+	if err != nil {
+		return err
+	}
+	exist, err := ws.Exist()
+	if err != nil {
+		return err
+	}
+	if exist && ws.Meta.Builtin != name {
+		return fmt.Errorf("WordSet %s already exist and is not installed from %s, use --name to choose another name", as, name)
+	}
+	if exist && ws.Meta.Revision >= builtin.Meta.Revision {
+		fmt.Printf("%s is up to date, revision %d\n", as, ws.Meta.Revision)
+		return nil
+	}
+
+	added := 0
+	for _, word := range builtin.SortedWords() {
+		if _, ok := ws.Words[word]; ok {
+			continue
+		}
+		detail := WordDetail{}
+		if d, ok := builtin.Details[word]; ok {
+			detail = *d
+		}
+		if ws.Put(word, detail) {
+			added++
+		}
+	}
+	ws.Meta.Description = builtin.Meta.Description
+	ws.Meta.Tags = builtin.Meta.Tags
+	ws.Meta.Language = builtin.Meta.Language
+	ws.Meta.Source = "builtin:" + name
+	ws.Meta.Builtin = name
+	ws.Meta.Revision = builtin.Meta.Revision
+	err = ws.Save(true)
+	if err != nil {
+		return err
+	}
+
+	if exist {
+		fmt.Printf("updated %s to revision %d, %d new words\n", as, builtin.Meta.Revision, added)
+	} else {
+		fmt.Printf("installed %s, %d words\n", as, added)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package wordset
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestBuiltin(t *testing.T) {
+	names := BuiltinNames()
+	if len(names) == 0 {
+		t.Fatal("no builtin word sets")
+	}
+	for _, name := range names {
+		ws, err := LoadBuiltin(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ws.Words) == 0 || ws.Meta.Revision <= 0 || ws.Meta.Description == "" {
+			t.Errorf("builtin %s: %d words, %+v", name, len(ws.Words), ws.Meta)
+		}
+	}
+	if _, err := LoadBuiltin("../wordset"); err == nil {
+		t.Errorf("LoadBuiltin accepted invalid name")
+	}
+}
+
+func TestInstall(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	m := WordSetManage{StoragePath: dir}
+	name := BuiltinNames()[0]
+	builtin, err := LoadBuiltin(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Add(name, []string{"apple"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Install(name, "") == nil {
+		t.Errorf("Install overwrote local word set %s", name)
+	}
+	err = m.Install(name, "mine")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟旧版本：缺少一个单词，并且有用户自己加入的单词
+	ws, err := m.Load("mine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ws.Words) != len(builtin.Words) || ws.Meta.Builtin != name || ws.Meta.Revision != builtin.Meta.Revision {
+		t.Fatalf("installed: %d words, %+v", len(ws.Words), ws.Meta)
+	}
+	missing := builtin.SortedWords()[0]
+	delete(ws.Words, missing)
+	ws.Put("my-own-word", WordDetail{Note: "mine"})
+	ws.Meta.Revision = 0
+	err = ws.Save(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Install(name, "mine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws, err = m.Load("mine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ws.Words[missing]; !ok {
+		t.Errorf("update did not add %s", missing)
+	}
+	if ws.Note("my-own-word") != "mine" || ws.Meta.Revision != builtin.Meta.Revision {
+		t.Errorf("update lost user words or revision: %d words, %+v", len(ws.Words), ws.Meta)
+	}
+}
@@ -1,27 +1,164 @@
 package wordset
 
 import (
+	"errors"
+
 	idictconfig "github.com/lai323/idict/config"
-	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 )
 
-func Start(config *idictconfig.Config, fs afero.Fs, wordSetImport *string, wordSetList *bool, wordSetShow *string, wordDel *string) func(*cobra.Command, []string) error {
+func manage(config *idictconfig.Config) (WordSetManage, error) {
+	if config.StoragePath == "" {
+		return WordSetManage{}, errors.New("StoragePath empty")
+	}
+	return WordSetManage{StoragePath: config.StoragePath, RestudyInterval: config.RestudyInterval}, nil
+}
 
+func List(config *idictconfig.Config) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
-		if *wordSetList {
-			return WordSetManage{StoragePath: config.StoragePath}.List()
+		m, err := manage(config)
+		if err != nil {
+			return err
 		}
-		if *wordSetShow != "" {
-			return WordSetManage{StoragePath: config.StoragePath}.Show(*wordSetShow)
+		return m.List()
+	}
+}
+
+func Show(config *idictconfig.Config, sortBy *string, state *string) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		m, err := manage(config)
+		if err != nil {
+			return err
 		}
-		if *wordSetImport != "" {
-			return WordSetManage{StoragePath: config.StoragePath}.Import(*wordSetImport)
+		return m.Show(args[0], *sortBy, *state)
+	}
+}
+
+func Install(config *idictconfig.Config, name *string) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		m, err := manage(config)
+		if err != nil {
+			return err
 		}
-		if *wordDel != "" {
-			return WordSetManage{StoragePath: config.StoragePath}.Del(*wordDel)
+		if *name != "" && len(args) > 1 {
+			return errors.New("--name can only be used when installing one word set")
+		}
+		for _, builtin := range args {
+			err = m.Install(builtin, *name)
+			if err != nil {
+				return err
+			}
 		}
-		cmd.Help()
 		return nil
 	}
 }
+
+func Add(config *idictconfig.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		m, err := manage(config)
+		if err != nil {
+			return err
+		}
+		return m.Add(args[0], args[1:])
+	}
+}
+
+func Remove(config *idictconfig.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		m, err := manage(config)
+		if err != nil {
+			return err
+		}
+		return m.Remove(args[0], args[1:])
+	}
+}
+
+func Delete(config *idictconfig.Config, force *bool) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		m, err := manage(config)
+		if err != nil {
+			return err
+		}
+		return m.Del(args[0], *force)
+	}
+}
+
+func Rename(config *idictconfig.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		m, err := manage(config)
+		if err != nil {
+			return err
+		}
+		return m.Rename(args[0], args[1])
+	}
+}
+
+func Merge(config *idictconfig.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		m, err := manage(config)
+		if err != nil {
+			return err
+		}
+		return m.Merge(args[0], args[1:])
+	}
+}
+
+func Intersect(config *idictconfig.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		m, err := manage(config)
+		if err != nil {
+			return err
+		}
+		return m.Intersect(args[0], args[1:])
+	}
+}
+
+func Subtract(config *idictconfig.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		m, err := manage(config)
+		if err != nil {
+			return err
+		}
+		return m.Subtract(args[0], args[1], args[2:])
+	}
+}
+
+func Dedupe(config *idictconfig.Config, remove *bool, byLemma *bool) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		m, err := manage(config)
+		if err != nil {
+			return err
+		}
+		return m.Dedupe(args, *remove, *byLemma)
+	}
+}
+
+func Diff(config *idictconfig.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		m, err := manage(config)
+		if err != nil {
+			return err
+		}
+		return m.Diff(args[0], args[1])
+	}
+}
+
+func Copy(config *idictconfig.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		m, err := manage(config)
+		if err != nil {
+			return err
+		}
+		return m.Copy(args[0], args[1])
+	}
+}
+
+// Prefetch 预先获取单词本中所有单词的翻译，获取由 prefetcher 完成
+func Prefetch(config *idictconfig.Config, prefetcher func(string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if _, err := manage(config); err != nil {
+			return err
+		}
+		return prefetcher(args[0])
+	}
+}
@@ -0,0 +1,199 @@
+package wordset
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+type wordExtent struct {
+	Count int
+	Last  int64
+}
+
+type PracExtent struct {
+	file            string
+	words           map[string]*wordExtent
+	RestudyInterval map[int]int
+}
+
+func (p *PracExtent) Save() error {
+	f, err := os.Create(p.file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	words := map[string]*wordExtent{}
+	for w, e := range p.words {
+		w = strings.TrimSpace(w)
+		w = strings.ToLower(w)
+		words[w] = e
+	}
+	p.words = words
+
+	b, err := json.Marshal(p.words)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	return err
+}
+
+func (p *PracExtent) Load() error {
+	filebyte, err := ioutil.ReadFile(p.file)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(filebyte, &p.words)
+}
+
+func (p *PracExtent) ReviewWords() []string {
+	words := []string{}
+	now := time.Now().Unix()
+	for word, extent := range p.words {
+		if p.needReview(extent, now) {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+func (p *PracExtent) needReview(extent *wordExtent, now int64) bool {
+	rememberCount := p.rememberCount()
+	if extent.Count >= rememberCount {
+		return false
+	}
+	for count, hour := range p.RestudyInterval {
+		if count == rememberCount {
+			continue
+		}
+		if extent.Count <= count && extent.Last+60*60*int64(hour) < now {
+			return true
+		}
+	}
+	return false
+}
+
+// 单词的练习状态
+const (
+	StateNew        = "new"
+	StateLearning   = "learning"
+	StateReview     = "review"
+	StateRemembered = "remembered"
+)
+
+var States = []string{StateNew, StateLearning, StateReview, StateRemembered}
+
+func (p *PracExtent) State(w string) string {
+	e, exist := p.words[w]
+	if !exist {
+		return StateNew
+	}
+	if e.Count >= p.rememberCount() {
+		return StateRemembered
+	}
+	if p.needReview(e, time.Now().Unix()) {
+		return StateReview
+	}
+	return StateLearning
+}
+
+// Last 返回单词最后一次练习的时间，没有练习过时为 0
+func (p *PracExtent) Last(w string) int64 {
+	e, exist := p.words[w]
+	if !exist {
+		return 0
+	}
+	return e.Last
+}
+
+func (p *PracExtent) RememberWords() []string {
+	words := []string{}
+	rememberCount := p.rememberCount()
+	for word, e := range p.words {
+		if e.Count >= rememberCount {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+func (p *PracExtent) Remembered(w string) bool {
+	e, exist := p.words[w]
+	return exist && e.Count >= p.rememberCount()
+}
+
+func (p *PracExtent) rememberCount() int {
+	rememberCount := 0
+	for count, hour := range p.RestudyInterval {
+		if hour == -1 {
+			rememberCount = count
+		}
+	}
+	return rememberCount
+}
+
+func (p *PracExtent) Remember(w string) error {
+	e, exist := p.words[w]
+	if !exist {
+		e = &wordExtent{}
+		p.words[w] = e
+	}
+	p.words[w].Count = e.Count + 1
+	p.words[w].Last = time.Now().Unix()
+	return p.Save()
+}
+
+func (p *PracExtent) Forget(w string) error {
+	e, exist := p.words[w]
+	if !exist {
+		e = &wordExtent{}
+		p.words[w] = e
+	}
+	p.words[w].Count = e.Count - 1
+	if p.words[w].Count < 0 {
+		p.words[w].Count = 0
+	}
+	p.words[w].Last = time.Now().Unix()
+	return p.Save()
+}
+
+// Reset 清除单词的练习进度，单词会立即进入复习
+func (p *PracExtent) Reset(w string) error {
+	p.words[w] = &wordExtent{}
+	return p.Save()
+}
+
+func (p *PracExtent) CorrectNum(w string) int {
+	e, exist := p.words[w]
+	if !exist {
+		return 0
+	}
+	return e.Count
+}
+
+// PracExtentFile 返回练习进度文件的位置，所有单词本共用一份进度
+func PracExtentFile(storagePath string) string {
+	return path.Join(storagePath, "practice_extent.json")
+}
+
+func NewPracExtent(f string, restudyInterval map[int]int) (PracExtent, error) {
+	p := PracExtent{file: f, RestudyInterval: restudyInterval}
+	if p.rememberCount() == 0 {
+		return p, fmt.Errorf("PracExtent rememberCount can not be 0")
+	}
+	_, err := os.Stat(f)
+	if os.IsNotExist(err) {
+		err := p.Save()
+		if err != nil {
+			return p, err
+		}
+	}
+	err = p.Load()
+	return p, err
+}
@@ -0,0 +1,108 @@
+package wordset
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// 单词本文件格式版本
+// 版本 1 为每行一个单词的纯文本，版本 2 为带元数据的 yaml，读取时两种格式都支持
+const WordSetVersion = 2
+
+type wordSetFile struct {
+	Version     int            `yaml:"version"`
+	Description string         `yaml:"description,omitempty"`
+	Source      string         `yaml:"source,omitempty"`
+	Tags        []string       `yaml:"tags,omitempty"`
+	Language    string         `yaml:"language,omitempty"`
+	Created     time.Time      `yaml:"created"`
+	Updated     time.Time      `yaml:"updated"`
+	Builtin     string         `yaml:"builtin,omitempty"`
+	Revision    int            `yaml:"revision,omitempty"`
+	Words       []wordSetEntry `yaml:"words"`
+}
+
+type wordSetEntry struct {
+	Text       string      `yaml:"text"`
+	Note       string      `yaml:"note,omitempty"`
+	Translates []Translate `yaml:"translates,omitempty"`
+	Sentences  []Sentence  `yaml:"sentences,omitempty"`
+}
+
+func isVersionedWordSet(filebyte []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(filebyte), []byte("version:"))
+}
+
+func decodeWordSet(ws *WordSet, filebyte []byte) error {
+	if ws.Details == nil {
+		ws.Details = map[string]*WordDetail{}
+	}
+	if !isVersionedWordSet(filebyte) {
+		// 无效的行在导入时已经报告过，这里直接忽略
+		words, _ := parseWords(string(filebyte))
+		for _, word := range words {
+			ws.Words[word] = 0
+		}
+		return nil
+	}
+
+	var f wordSetFile
+	err := yaml.Unmarshal(filebyte, &f)
+	if err != nil {
+		return err
+	}
+	if f.Version > WordSetVersion {
+		return fmt.Errorf("unsupported wordset version %d", f.Version)
+	}
+	ws.Meta = WordSetMeta{
+		Description: f.Description,
+		Source:      f.Source,
+		Tags:        f.Tags,
+		Language:    f.Language,
+		Created:     f.Created,
+		Updated:     f.Updated,
+		Builtin:     f.Builtin,
+		Revision:    f.Revision,
+	}
+	for _, entry := range f.Words {
+		ws.Put(entry.Text, WordDetail{
+			Note:       strings.TrimSpace(entry.Note),
+			Translates: entry.Translates,
+			Sentences:  entry.Sentences,
+		})
+	}
+	return nil
+}
+
+func encodeWordSet(ws *WordSet) ([]byte, error) {
+	language := ws.Meta.Language
+	if language == "" {
+		language = "en"
+	}
+	f := wordSetFile{
+		Version:     WordSetVersion,
+		Description: ws.Meta.Description,
+		Source:      ws.Meta.Source,
+		Tags:        ws.Meta.Tags,
+		Language:    language,
+		Created:     ws.Meta.Created,
+		Updated:     ws.Meta.Updated,
+		Builtin:     ws.Meta.Builtin,
+		Revision:    ws.Meta.Revision,
+		Words:       []wordSetEntry{},
+	}
+	for _, word := range ws.SortedWords() {
+		entry := wordSetEntry{Text: NormalizeWord(word)}
+		if d, ok := ws.Details[word]; ok {
+			entry.Note = d.Note
+			entry.Translates = d.Translates
+			entry.Sentences = d.Sentences
+		}
+		f.Words = append(f.Words, entry)
+	}
+	return yaml.Marshal(&f)
+}
@@ -0,0 +1,83 @@
+package wordset
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestWordSetPlainFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(path.Join(dir, "old.wordset"), []byte("apple\nTake Off\n\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws, err := NewWordSet("old", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ws.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ws.Words) != 2 || !ws.Meta.Created.IsZero() {
+		t.Fatalf("Load plain: %v %v", ws.Words, ws.Meta)
+	}
+	if _, ok := ws.Words["take off"]; !ok {
+		t.Errorf("phrase not normalised: %v", ws.Words)
+	}
+}
+
+func TestWordSetVersionedFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ws, err := NewWordSet("meta", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.Meta.Description = "words from docs"
+	ws.Meta.Source = "api.md"
+	ws.Meta.Tags = []string{"work", "api"}
+	ws.Words["idempotent"] = 0
+	ws.Words["apple"] = 0
+	ws.Details["idempotent"] = &WordDetail{Note: "used in our API docs"}
+	err = ws.Save(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filebyte, _ := ioutil.ReadFile(ws.fileName())
+	if !strings.HasPrefix(string(filebyte), "version: 2\n") {
+		t.Errorf("saved file:\n%s", filebyte)
+	}
+
+	loaded, _ := NewWordSet("meta", dir)
+	err = loaded.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Meta.Description != "words from docs" || loaded.Meta.Source != "api.md" ||
+		len(loaded.Meta.Tags) != 2 || loaded.Meta.Language != "en" {
+		t.Errorf("meta %+v", loaded.Meta)
+	}
+	if !loaded.Meta.Created.Equal(ws.Meta.Created) || loaded.Meta.Updated.IsZero() {
+		t.Errorf("times %v %v", loaded.Meta.Created, ws.Meta.Created)
+	}
+	if len(loaded.Words) != 2 || loaded.Note("idempotent") != "used in our API docs" || loaded.Note("apple") != "" {
+		t.Errorf("words %v notes %v", loaded.Words, loaded.Details)
+	}
+	if words := loaded.SortedWords(); words[0] != "apple" {
+		t.Errorf("SortedWords %v", words)
+	}
+}
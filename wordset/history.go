@@ -0,0 +1,163 @@
+package wordset
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HistoryEntry 为一次查询，Query 为输入的内容，与单词相同时为空
+type HistoryEntry struct {
+	Word  string `json:"word"`
+	Query string `json:"query,omitempty"`
+	Time  int64  `json:"time"`
+}
+
+// History 为查询记录，每行一条 JSON，只追加不修改
+type History struct {
+	file string
+}
+
+// HistoryFile 返回查询记录文件的位置
+func HistoryFile(storagePath string) string {
+	return path.Join(storagePath, "history.jsonl")
+}
+
+func NewHistory(storagePath string) History {
+	return History{file: HistoryFile(storagePath)}
+}
+
+// Add 记录一次查询，没有设置文件时不记录
+func (h History) Add(word, query string) error {
+	word = NormalizeText(word)
+	if word == "" || h.file == "" {
+		return nil
+	}
+	entry := HistoryEntry{Word: word, Time: time.Now().Unix()}
+	if q := strings.TrimSpace(query); q != "" && strings.ToLower(q) != word {
+		entry.Query = q
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("History Add %s", err.Error())
+	}
+	defer f.Close()
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
+
+// Entries 按时间顺序返回所有记录，无法解析的行会被跳过
+func (h History) Entries() ([]HistoryEntry, error) {
+	f, err := os.Open(h.file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("History Entries %s", err.Error())
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e HistoryEntry
+		if json.Unmarshal(scanner.Bytes(), &e) != nil || e.Word == "" {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time < entries[j].Time })
+	return entries, scanner.Err()
+}
+
+// EntriesFrom 从文件的 offset 位置开始读取完整的记录，返回记录和下次读取的位置，用于增量读取
+// 最后一行还没有写完时不读取，下次从这一行重新开始
+func (h History) EntriesFrom(offset int64) ([]HistoryEntry, int64, error) {
+	f, err := os.Open(h.file)
+	if os.IsNotExist(err) {
+		return nil, offset, nil
+	}
+	if err != nil {
+		return nil, offset, fmt.Errorf("History EntriesFrom %s", err.Error())
+	}
+	defer f.Close()
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, offset, fmt.Errorf("History EntriesFrom %s", err.Error())
+	}
+
+	var entries []HistoryEntry
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return entries, offset, nil
+		}
+		if err != nil {
+			return entries, offset, fmt.Errorf("History EntriesFrom %s", err.Error())
+		}
+		offset += int64(len(line))
+		var e HistoryEntry
+		if json.Unmarshal(line, &e) != nil || e.Word == "" {
+			continue
+		}
+		entries = append(entries, e)
+	}
+}
+
+// Counts 返回每个单词的查询次数
+func (h History) Counts() (map[string]int, error) {
+	entries, err := h.Entries()
+	if err != nil {
+		return nil, err
+	}
+	return HistoryCounts(entries), nil
+}
+
+func HistoryCounts(entries []HistoryEntry) map[string]int {
+	counts := map[string]int{}
+	for _, e := range entries {
+		counts[e.Word]++
+	}
+	return counts
+}
+
+// FilterHistory 返回 since 之后并且单词或输入匹配 grep 的记录，since 为零值或 grep 为 nil 时不过滤
+func FilterHistory(entries []HistoryEntry, since time.Time, grep *regexp.Regexp) []HistoryEntry {
+	var filtered []HistoryEntry
+	for _, e := range entries {
+		if !since.IsZero() && e.Time < since.Unix() {
+			continue
+		}
+		if grep != nil && !grep.MatchString(e.Word) && !grep.MatchString(e.Query) {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+	return filtered
+}
+
+// RecentWords 返回最近查询的不重复的单词，最近的在前
+func RecentWords(entries []HistoryEntry, limit int) []string {
+	seen := map[string]bool{}
+	var words []string
+	for i := len(entries) - 1; i >= 0 && len(words) < limit; i-- {
+		if seen[entries[i].Word] {
+			continue
+		}
+		seen[entries[i].Word] = true
+		words = append(words, entries[i].Word)
+	}
+	return words
+}
@@ -0,0 +1,76 @@
+package wordset
+
+import (
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	h := NewHistory(dir)
+
+	entries, err := h.Entries()
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("empty history %v %v", entries, err)
+	}
+	for _, q := range [][2]string{{"apple", "Apple"}, {"run", "running"}, {"apple", "apple"}, {"take off", "take  off"}} {
+		err = h.Add(q[0], q[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 损坏的行会被跳过
+	f, err := os.OpenFile(HistoryFile(dir), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("{broken\n")
+	f.Close()
+
+	entries, err = h.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 || entries[1].Query != "running" || entries[0].Query != "" {
+		t.Errorf("entries %v", entries)
+	}
+	counts, err := h.Counts()
+	if err != nil || counts["apple"] != 2 || counts["take off"] != 1 {
+		t.Errorf("counts %v %v", counts, err)
+	}
+	// 增量读取时不读取还没有写完的行
+	part, offset, err := h.EntriesFrom(0)
+	if err != nil || len(part) != 4 {
+		t.Fatalf("from start %v %v", part, err)
+	}
+	f, err = os.OpenFile(HistoryFile(dir), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"word":"pear"}` + "\n" + `{"word":"ki`)
+	f.Close()
+	part, offset, err = h.EntriesFrom(offset)
+	if err != nil || len(part) != 1 || part[0].Word != "pear" {
+		t.Errorf("from offset %v %v", part, err)
+	}
+	if part, _, _ = h.EntriesFrom(offset); len(part) != 0 {
+		t.Errorf("partial line %v", part)
+	}
+	if got := strings.Join(RecentWords(entries, 10), ","); got != "take off,apple,run" {
+		t.Errorf("recent %s", got)
+	}
+	if got := FilterHistory(entries, time.Time{}, regexp.MustCompile("^run")); len(got) != 1 || got[0].Word != "run" {
+		t.Errorf("grep %v", got)
+	}
+	if got := FilterHistory(entries, time.Now().Add(time.Hour), nil); len(got) != 0 {
+		t.Errorf("since %v", got)
+	}
+}
@@ -0,0 +1,245 @@
+package wordset
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// UserEntry 为自己定义的词条，查询时合并到词典的结果中
+// Replace 时只使用自己的翻译，不合并词典的翻译，有翻译并且 Replace 时不会查询词典
+type UserEntry struct {
+	Replace    bool        `yaml:"replace,omitempty"`
+	US         string      `yaml:"us,omitempty"`
+	UK         string      `yaml:"uk,omitempty"`
+	Translates []Translate `yaml:"translates,omitempty"`
+	Phrases    []Phrase    `yaml:"phrases,omitempty"`
+	Sentences  []Sentence  `yaml:"sentences,omitempty"`
+}
+
+// Complete 为 true 时不需要查询词典
+func (e UserEntry) Complete() bool {
+	return e.Replace && len(e.Translates) != 0
+}
+
+// UserDict 为自己的词典，保存在 StoragePath/userdict.yaml，可以直接编辑
+type UserDict struct {
+	file  string
+	words map[string]UserEntry
+}
+
+func UserDictFile(storagePath string) string {
+	return path.Join(storagePath, "userdict.yaml")
+}
+
+func NewUserDict(storagePath string) (UserDict, error) {
+	d := UserDict{file: UserDictFile(storagePath), words: map[string]UserEntry{}}
+	filebyte, err := ioutil.ReadFile(d.file)
+	if os.IsNotExist(err) {
+		return d, nil
+	}
+	if err != nil {
+		return d, fmt.Errorf("UserDict read %s", err.Error())
+	}
+	words, err := ParseUserDict(filebyte)
+	if err != nil {
+		return d, fmt.Errorf("UserDict %s %s", d.file, err.Error())
+	}
+	d.words = words
+	return d, nil
+}
+
+// ParseUserDict 解析 yaml 格式的词条，单词为键
+func ParseUserDict(filebyte []byte) (map[string]UserEntry, error) {
+	var raw map[string]UserEntry
+	err := yaml.UnmarshalStrict(filebyte, &raw)
+	if err != nil {
+		return nil, err
+	}
+	words := map[string]UserEntry{}
+	for w, e := range raw {
+		if !ValidWord(w) {
+			return nil, fmt.Errorf("invalid word %q", w)
+		}
+		words[NormalizeWord(w)] = e
+	}
+	return words, nil
+}
+
+func (d UserDict) File() string {
+	return d.file
+}
+
+func (d UserDict) Get(word string) (UserEntry, bool) {
+	e, ok := d.words[NormalizeWord(word)]
+	return e, ok
+}
+
+func (d UserDict) Words() []string {
+	var words []string
+	for w := range d.words {
+		words = append(words, w)
+	}
+	sort.Strings(words)
+	return words
+}
+
+// Import 加入或覆盖词条，返回加入和覆盖的数量
+// 文件可能是手动编辑的，只替换被覆盖的词条，新的词条加在最后，保留其他内容和注释
+func (d UserDict) Import(words map[string]UserEntry) (added, replaced int, err error) {
+	content, err := ioutil.ReadFile(d.file)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, 0, fmt.Errorf("UserDict read %s", err.Error())
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	blocks := userDictBlocks(lines)
+
+	var newWords []string
+	replace := map[int]string{}
+	for w, e := range words {
+		if _, exist := d.words[w]; exist {
+			replaced++
+		} else {
+			added++
+			newWords = append(newWords, w)
+		}
+		d.words[w] = e
+		for i, b := range blocks {
+			if b.word == w {
+				replace[i] = w
+			}
+		}
+	}
+	sort.Strings(newWords)
+
+	var out strings.Builder
+	pos := 0
+	for i, b := range blocks {
+		w, ok := replace[i]
+		if !ok {
+			continue
+		}
+		out.WriteString(strings.Join(lines[pos:b.start], ""))
+		entry, err := marshalUserEntry(w, d.words[w])
+		if err != nil {
+			return 0, 0, err
+		}
+		out.WriteString(entry)
+		pos = b.end
+	}
+	out.WriteString(strings.Join(lines[pos:], ""))
+	if out.Len() != 0 && !strings.HasSuffix(out.String(), "\n") {
+		out.WriteString("\n")
+	}
+	for _, w := range newWords {
+		entry, err := marshalUserEntry(w, d.words[w])
+		if err != nil {
+			return 0, 0, err
+		}
+		out.WriteString(entry)
+	}
+
+	filebyte := []byte(out.String())
+	// 无法按行找到词条时，例如使用了 flow 格式，重新生成整个文件
+	parsed, err := ParseUserDict(filebyte)
+	if err != nil || !reflect.DeepEqual(parsed, d.words) {
+		filebyte, err = yaml.Marshal(d.words)
+		if err != nil {
+			return 0, 0, fmt.Errorf("UserDict encode %s", err.Error())
+		}
+	}
+	return added, replaced, ioutil.WriteFile(d.file, filebyte, 0644)
+}
+
+func marshalUserEntry(word string, e UserEntry) (string, error) {
+	b, err := yaml.Marshal(map[string]UserEntry{word: e})
+	if err != nil {
+		return "", fmt.Errorf("UserDict encode %s", err.Error())
+	}
+	return string(b), nil
+}
+
+// userDictBlock 为文件中一个词条所在的行，不包括后面的空行和注释
+type userDictBlock struct {
+	word       string
+	start, end int
+}
+
+// userDictBlocks 按行找到顶层的词条，词条从没有缩进的键开始，到下一个没有缩进的键结束
+func userDictBlocks(lines []string) []userDictBlock {
+	var blocks []userDictBlock
+	last := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' || line[0] == '-' {
+			last = i
+			continue
+		}
+		if len(blocks) != 0 {
+			blocks[len(blocks)-1].end = last + 1
+		}
+		var key map[string]interface{}
+		word := ""
+		if yaml.Unmarshal([]byte(strings.SplitN(trimmed, ":", 2)[0]+": 0"), &key) == nil {
+			for k := range key {
+				word = NormalizeWord(k)
+			}
+		}
+		blocks = append(blocks, userDictBlock{word: word, start: i})
+		last = i
+	}
+	if len(blocks) != 0 {
+		blocks[len(blocks)-1].end = last + 1
+	}
+	return blocks
+}
+
+// Merge 把自己的词条合并到词典的结果中，自己的音标、翻译和例句优先
+func (d UserDict) Merge(word Word) Word {
+	e, ok := d.Get(word.Text)
+	if !ok {
+		return word
+	}
+	return e.Merge(word)
+}
+
+func (e UserEntry) Merge(word Word) Word {
+	if e.US != "" {
+		word.PronounceUS.Phonetic = e.US
+	}
+	if e.UK != "" {
+		word.PronounceUK.Phonetic = e.UK
+	}
+	translates := append([]Translate{}, e.Translates...)
+	if !e.Replace || len(e.Translates) == 0 {
+		for _, t := range word.Translates {
+			if !containsTranslate(translates, t) {
+				translates = append(translates, t)
+			}
+		}
+	}
+	word.Translates = translates
+	word.Phrases = append(append([]Phrase{}, e.Phrases...), word.Phrases...)
+	sentences := append([]Sentence{}, e.Sentences...)
+	for i := range sentences {
+		if sentences[i].Word == "" {
+			sentences[i].Word = word.Text
+		}
+	}
+	for _, sen := range word.Sentences {
+		if !containsSentence(sentences, sen) {
+			sentences = append(sentences, sen)
+		}
+	}
+	word.Sentences = sentences
+	return word
+}
@@ -0,0 +1,120 @@
+package wordset
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+const userDictYaml = `
+Idempotent:
+  replace: true
+  us: /ˌaɪdəmˈpoʊtənt/
+  translates:
+  - part: adj.
+    mean: 幂等的
+apple:
+  translates:
+  - mean: 苹果公司
+  sentences:
+  - text: I work at Apple.
+    trans: 我在苹果公司工作。
+`
+
+func TestUserDict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := ParseUserDict([]byte("apple:\n  mean: x\n")); err == nil {
+		t.Errorf("unknown field accepted")
+	}
+	if _, err := ParseUserDict([]byte("bad1:\n  us: x\n")); err == nil {
+		t.Errorf("invalid word accepted")
+	}
+	words, err := ParseUserDict([]byte(userDictYaml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewUserDict(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, replaced, err := d.Import(words)
+	if err != nil || added != 2 || replaced != 0 {
+		t.Fatalf("import %d %d %v", added, replaced, err)
+	}
+	d, err = NewUserDict(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, ok := d.Get("idempotent")
+	if !ok || !e.Complete() {
+		t.Fatalf("get %+v", e)
+	}
+
+	provider := Word{
+		Text:        "idempotent",
+		PronounceUS: Pronounce{Phonetic: "/old/"},
+		Translates:  []Translate{{Part: "adj.", Mean: "等幂的"}},
+	}
+	word := d.Merge(provider)
+	if word.PronounceUS.Phonetic != "/ˌaɪdəmˈpoʊtənt/" || len(word.Translates) != 1 || word.Translates[0].Mean != "幂等的" {
+		t.Errorf("replace %+v", word)
+	}
+	word = d.Merge(Word{Text: "apple", Translates: []Translate{{Part: "n.", Mean: "苹果"}}})
+	if len(word.Translates) != 2 || word.Translates[0].Mean != "苹果公司" || len(word.Sentences) != 1 || word.Sentences[0].Word != "apple" {
+		t.Errorf("merge %+v", word)
+	}
+	if word := d.Merge(Word{Text: "pear"}); len(word.Translates) != 0 {
+		t.Errorf("pear %+v", word)
+	}
+}
+
+func TestUserDictImportKeepsComments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	content := "# 工作中的术语\n" + strings.TrimLeft(userDictYaml, "\n") + "\n# 最后的注释\nzebra: # 斑马\n  translates:\n  - mean: 斑马\n"
+	err = ioutil.WriteFile(UserDictFile(dir), []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewUserDict(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	words, err := ParseUserDict([]byte("apple:\n  translates:\n  - mean: 苹果\nsharding:\n  translates:\n  - mean: 分片\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, replaced, err := d.Import(words)
+	if err != nil || added != 1 || replaced != 1 {
+		t.Fatalf("import %d %d %v", added, replaced, err)
+	}
+	filebyte, err := ioutil.ReadFile(UserDictFile(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(filebyte)
+	for _, keep := range []string{"# 工作中的术语\nIdempotent:\n", "# 最后的注释\nzebra: # 斑马\n", "mean: 分片"} {
+		if !strings.Contains(text, keep) {
+			t.Errorf("missing %q in\n%s", keep, text)
+		}
+	}
+	if strings.Contains(text, "苹果公司") || !strings.HasPrefix(text, "# 工作中的术语\n") || !strings.HasSuffix(text, "mean: 分片\n") {
+		t.Errorf("userdict\n%s", text)
+	}
+	d, err = NewUserDict(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := d.Get("apple"); !ok || e.Translates[0].Mean != "苹果" || len(e.Sentences) != 0 || len(d.Words()) != 4 {
+		t.Errorf("reloaded %+v %v", e, d.Words())
+	}
+}
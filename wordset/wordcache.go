@@ -1,66 +1,346 @@
 package wordset
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/spf13/afero"
 )
 
+// 文件名中保留的可读部分的最大长度，剩余部分由 hash 区分
+const cacheKeyNameMax = 48
+
 type WordCache struct {
 	StorageDir string
+	// 外部命令查询的单词放在 providers 下单独的目录中，为空时为在线词典的缓存
+	ProviderDir string
+	// 写入缓存时记录的词典来源和解析器版本，解析器版本更新后旧的缓存会被重新获取
+	Provider      string
+	ParserVersion int
+	// 缓存有效期，0 表示永不过期
+	TTL time.Duration
+}
+
+type CacheMeta struct {
+	FetchedAt     int64
+	Provider      string
+	ParserVersion int
+}
+
+type CacheEntry struct {
+	Meta CacheMeta
+	Word Word
+}
+
+// 兼容没有 Meta 的旧缓存文件，旧文件的内容直接就是 Word
+func (e *CacheEntry) UnmarshalJSON(b []byte) error {
+	var entry struct {
+		Meta CacheMeta
+		Word *Word
+	}
+	err := json.Unmarshal(b, &entry)
+	if err != nil {
+		return err
+	}
+	if entry.Word != nil {
+		e.Meta = entry.Meta
+		e.Word = *entry.Word
+		return nil
+	}
+	e.Meta = CacheMeta{}
+	return json.Unmarshal(b, &e.Word)
 }
 
 func NewWordCache(dir string) (WordCache, error) {
-	wordcache := WordCache{StorageDir: dir}
+	return newWordCache(WordCache{StorageDir: dir})
+}
+
+// NewProviderWordCache 返回外部命令使用的缓存，不同命令查询的结果不会互相覆盖
+func NewProviderWordCache(dir, provider string) (WordCache, error) {
+	if !ValidName(provider) {
+		return WordCache{}, fmt.Errorf("WordCache invalid provider name %q", provider)
+	}
+	return newWordCache(WordCache{StorageDir: dir, ProviderDir: provider, Provider: provider})
+}
+
+func newWordCache(wordcache WordCache) (WordCache, error) {
 	err := afero.NewOsFs().MkdirAll(wordcache.CacheDir(), 0755)
 	if err != nil {
 		return wordcache, fmt.Errorf("WordCache MkdirAll %s", err.Error())
 	}
+	err = wordcache.migrate()
+	if err != nil {
+		return wordcache, fmt.Errorf("WordCache migrate %s", err.Error())
+	}
 	return wordcache, nil
 }
 
 func (c WordCache) CacheDir() string {
+	if c.ProviderDir != "" {
+		return fmt.Sprintf("%s/providers/%s/wordcache", c.StorageDir, c.ProviderDir)
+	}
 	return fmt.Sprintf("%s/wordcache", c.StorageDir)
 }
 
+// NormalizeText 统一查询文本的大小写和空白，相同的单词或句子总是得到相同的缓存 key
+func NormalizeText(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// CacheKey 返回 text 在缓存目录中的相对路径
+func CacheKey(text string) string {
+	return CacheName(text) + ".json"
+}
+
+// CacheName 返回 text 对应的不带扩展名的缓存文件相对路径
+// 格式为 <hash 前两位>/<可读部分>-<hash>，可读部分只包含 [a-z0-9-]，
+// 所以任何输入都不会逃出缓存目录，也不会超出文件名长度限制
+func CacheName(text string) string {
+	norm := NormalizeText(text)
+	sum := sha1.Sum([]byte(norm))
+	hash := hex.EncodeToString(sum[:])
+
+	var name strings.Builder
+	dash := false
+	for _, r := range norm {
+		if name.Len() >= cacheKeyNameMax {
+			break
+		}
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			name.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && name.Len() != 0 {
+			name.WriteRune('-')
+			dash = true
+		}
+	}
+
+	filename := strings.TrimRight(name.String(), "-")
+	if filename == "" {
+		filename = hash
+	} else {
+		filename = filename + "-" + hash[:16]
+	}
+	return path.Join(hash[:2], filename)
+}
+
+func (c WordCache) file(text string) string {
+	return path.Join(c.CacheDir(), CacheKey(text))
+}
+
 func (c WordCache) Get(text string) (Word, bool, error) {
+	entry, exist, err := c.Entry(text)
+	return entry.Word, exist, err
+}
+
+func (c WordCache) Entry(text string) (CacheEntry, bool, error) {
 	var err error
-	var word Word
+	var entry CacheEntry
 
-	file := path.Join(c.CacheDir(), text)
+	file := c.file(text)
 	_, err = os.Stat(file)
 	if os.IsNotExist(err) {
-		return word, false, nil
+		return entry, false, nil
 	}
 	if err != nil {
-		return word, false, fmt.Errorf("WordCache Get %s", err.Error())
+		return entry, false, fmt.Errorf("WordCache Get %s", err.Error())
 	}
 
+	entry, err = readCacheEntry(file)
+	if err != nil {
+		return entry, false, err
+	}
+	return entry, true, nil
+}
+
+func readCacheEntry(file string) (CacheEntry, error) {
+	var entry CacheEntry
 	filebyte, err := ioutil.ReadFile(file)
 	if err != nil {
-		return word, false, fmt.Errorf("WordCache read file %s %s", file, err.Error())
+		return entry, fmt.Errorf("WordCache read file %s %s", file, err.Error())
 	}
-	err = json.Unmarshal(filebyte, &word)
+	err = json.Unmarshal(filebyte, &entry)
 	if err != nil {
-		return word, false, fmt.Errorf("WordCache Get json Unmarshal %s %s", file, err.Error())
+		return entry, fmt.Errorf("WordCache Get json Unmarshal %s %s", file, err.Error())
 	}
-
-	return word, true, nil
+	return entry, nil
 }
 
 func (c WordCache) Set(word Word) error {
-	file := path.Join(c.CacheDir(), word.Text)
-	filebyte, err := json.Marshal(word)
+	return c.SetEntry(CacheEntry{
+		Meta: CacheMeta{
+			FetchedAt:     time.Now().Unix(),
+			Provider:      c.Provider,
+			ParserVersion: c.ParserVersion,
+		},
+		Word: word,
+	})
+}
+
+func (c WordCache) SetEntry(entry CacheEntry) error {
+	file := c.file(entry.Word.Text)
+	filebyte, err := json.Marshal(entry)
 	if err != nil {
 		return fmt.Errorf("WordCache Set json Unmarshal %s %s", file, err.Error())
 	}
-	err = ioutil.WriteFile(file, filebyte, 0644)
+	err = os.MkdirAll(path.Dir(file), 0755)
+	if err != nil {
+		return fmt.Errorf("WordCache MkdirAll %s %s", file, err.Error())
+	}
+	// 避免并发获取同一个单词时读到写了一半的文件
+	err = writeFileAtomic(file, filebyte)
 	if err != nil {
 		return fmt.Errorf("WordCache WriteFile %s %s", file, err.Error())
 	}
 	return nil
 }
+
+// writeFileAtomic 先写临时文件再重命名，其他进程不会读到写了一半的文件
+func writeFileAtomic(file string, filebyte []byte) error {
+	tmp, err := ioutil.TempFile(path.Dir(file), ".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(filebyte)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+func (c WordCache) Remove(text string) error {
+	err := os.Remove(c.file(text))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("WordCache Remove %s", err.Error())
+	}
+	return nil
+}
+
+// Expired 检查缓存是否需要重新获取：
+// 超过有效期、由其他词典或旧版本解析器生成、或者当时没有解析出任何翻译
+func (c WordCache) Expired(entry CacheEntry) bool {
+	if len(entry.Word.Translates) == 0 {
+		return true
+	}
+	// 由其他词典获取
+	if entry.Meta.Provider != "" && entry.Meta.Provider != c.Provider {
+		return true
+	}
+	if entry.Meta.ParserVersion < c.ParserVersion {
+		return true
+	}
+	if c.TTL > 0 && time.Since(time.Unix(entry.Meta.FetchedAt, 0)) > c.TTL {
+		return true
+	}
+	return false
+}
+
+// Walk 按单词顺序遍历所有缓存
+func (c WordCache) Walk(fn func(file string, entry CacheEntry) error) error {
+	shards, err := ioutil.ReadDir(c.CacheDir())
+	if err != nil {
+		return fmt.Errorf("WordCache ReadDir %s", err.Error())
+	}
+	var files []string
+	for _, shard := range shards {
+		if !shard.IsDir() {
+			continue
+		}
+		dir := path.Join(c.CacheDir(), shard.Name())
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("WordCache ReadDir %s", err.Error())
+		}
+		for _, info := range infos {
+			if info.Mode().IsRegular() && path.Ext(info.Name()) == ".json" {
+				files = append(files, path.Join(dir, info.Name()))
+			}
+		}
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		entry, err := readCacheEntry(file)
+		if err != nil {
+			return err
+		}
+		err = fn(file, entry)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 旧版本直接用查询文本作为文件名存放在缓存目录下，
+// 新版本的缓存都在分片子目录中，缓存目录下能解析为单词的普通文件是旧格式，迁移后删除，其他文件保留
+func (c WordCache) migrate() error {
+	if c.ProviderDir != "" {
+		return nil
+	}
+	files, err := ioutil.ReadDir(c.CacheDir())
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || !f.Mode().IsRegular() {
+			continue
+		}
+		old := path.Join(c.CacheDir(), f.Name())
+		filebyte, err := ioutil.ReadFile(old)
+		if err != nil {
+			return fmt.Errorf("read file %s %s", old, err.Error())
+		}
+
+		var entry CacheEntry
+		err = json.Unmarshal(filebyte, &entry)
+		word := entry.Word
+		if err != nil || (word.Text == "" && len(word.Translates) == 0 && word.PronounceUS.Phonetic == "" && word.PronounceUK.Phonetic == "") {
+			continue
+		}
+		if entry.Word.Text == "" {
+			if !utf8.ValidString(f.Name()) {
+				continue
+			}
+			entry.Word.Text = f.Name()
+		}
+		// 旧缓存没有元数据，用文件修改时间作为获取时间，解析器版本为 0
+		if entry.Meta.FetchedAt == 0 {
+			entry.Meta.FetchedAt = f.ModTime().Unix()
+		}
+		_, exist, err := c.Get(entry.Word.Text)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			err = c.SetEntry(entry)
+			if err != nil {
+				return err
+			}
+		}
+		err = os.Remove(old)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
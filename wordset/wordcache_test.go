@@ -0,0 +1,178 @@
+package wordset
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func checkCacheFile(t *testing.T, c WordCache, text string) {
+	file := c.file(text)
+	rel, err := filepath.Rel(c.CacheDir(), file)
+	if err != nil {
+		t.Fatalf("Rel %q: %s", text, err)
+	}
+	if rel == "." || strings.HasPrefix(rel, "..") || filepath.IsAbs(rel) {
+		t.Fatalf("cache file of %q outside CacheDir: %s", text, file)
+	}
+	if len(strings.Split(rel, string(filepath.Separator))) != 2 {
+		t.Fatalf("cache file of %q not in a shard dir: %s", text, rel)
+	}
+	if len(filepath.Base(file)) > 255 {
+		t.Fatalf("cache file name of %q too long: %d", text, len(filepath.Base(file)))
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	c := WordCache{StorageDir: "/tmp/idict"}
+	for _, text := range []string{
+		"abc", "../../etc/passwd", "/", "..", ".", "", "a/b", "take off",
+		"你好", strings.Repeat("long sentence ", 100), "a\x00b",
+	} {
+		checkCacheFile(t, c, text)
+	}
+
+	if CacheKey("Take  Off ") != CacheKey("take off") {
+		t.Errorf("CacheKey not normalised: %s %s", CacheKey("Take  Off "), CacheKey("take off"))
+	}
+	if CacheKey("a/b") == CacheKey("a b") {
+		t.Errorf("CacheKey collision: %s", CacheKey("a/b"))
+	}
+}
+
+func FuzzCacheKey(f *testing.F) {
+	for _, seed := range []string{"abc", "../x", "a/../../b", "你好", "take off"} {
+		f.Add(seed)
+	}
+	c := WordCache{StorageDir: "/tmp/idict"}
+	f.Fuzz(func(t *testing.T, text string) {
+		checkCacheFile(t, c, text)
+	})
+}
+
+func TestWordCacheSetGet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c, err := NewWordCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"hello", "a/b/../c", "..", "Hello World"} {
+		err = c.Set(Word{Text: text})
+		if err != nil {
+			t.Fatal(err)
+		}
+		word, exist, err := c.Get(text)
+		if err != nil || !exist || word.Text != text {
+			t.Fatalf("Get %q: %v %v %v", text, word, exist, err)
+		}
+	}
+	if _, err := os.Stat(path.Join(dir, "c")); !os.IsNotExist(err) {
+		t.Fatalf("cache written outside CacheDir")
+	}
+}
+
+func TestWordCacheMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := WordCache{StorageDir: dir}
+	err = os.MkdirAll(c.CacheDir(), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := Word{Text: "guess", Translates: []Translate{{Part: "v.", Mean: "猜"}}}
+	b, _ := json.Marshal(old)
+	err = ioutil.WriteFile(path.Join(c.CacheDir(), "guess"), b, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = NewWordCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	word, exist, err := c.Get("guess")
+	if err != nil || !exist || len(word.Translates) != 1 {
+		t.Fatalf("Get migrated word: %v %v %v", word, exist, err)
+	}
+	if _, err := os.Stat(path.Join(c.CacheDir(), "guess")); !os.IsNotExist(err) {
+		t.Fatalf("old cache file not removed")
+	}
+
+	// 不是旧缓存的文件保留
+	for name, content := range map[string]string{"notes.txt": "hello", "empty.json": "{}"} {
+		file := path.Join(c.CacheDir(), name)
+		err = ioutil.WriteFile(file, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = NewWordCache(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("%s removed: %v", name, err)
+		}
+	}
+}
+
+func TestWordCacheExpired(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c, err := NewWordCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Provider = "eudic"
+	c.ParserVersion = 2
+	c.TTL = time.Hour
+
+	word := Word{Text: "guess", Translates: []Translate{{Mean: "猜"}}}
+	err = c.Set(word)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, exist, err := c.Entry("guess")
+	if err != nil || !exist {
+		t.Fatalf("Entry: %v %v", exist, err)
+	}
+	if entry.Meta.Provider != "eudic" || entry.Meta.ParserVersion != 2 || c.Expired(entry) {
+		t.Errorf("fresh entry: %+v", entry.Meta)
+	}
+
+	for name, e := range map[string]CacheEntry{
+		"old parser": {Meta: CacheMeta{FetchedAt: time.Now().Unix(), Provider: "eudic", ParserVersion: 1}, Word: word},
+		"ttl":        {Meta: CacheMeta{FetchedAt: time.Now().Add(-2 * time.Hour).Unix(), Provider: "eudic", ParserVersion: 2}, Word: word},
+		"provider":   {Meta: CacheMeta{FetchedAt: time.Now().Unix(), Provider: "glossary", ParserVersion: 2}, Word: word},
+		"empty":      {Meta: CacheMeta{FetchedAt: time.Now().Unix(), Provider: "eudic", ParserVersion: 2}, Word: Word{Text: "guess"}},
+	} {
+		if !c.Expired(e) {
+			t.Errorf("%s entry not expired", name)
+		}
+	}
+
+	// 旧格式的缓存内容直接就是 Word
+	var old CacheEntry
+	b, _ := json.Marshal(word)
+	err = json.Unmarshal(b, &old)
+	if err != nil || old.Word.Text != "guess" || old.Meta.ParserVersion != 0 {
+		t.Errorf("Unmarshal old format: %+v %v", old, err)
+	}
+}
@@ -1,21 +1,54 @@
 package wordset
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
 	"regexp"
+	"sort"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/afero"
 )
 
+type WordSetMeta struct {
+	Description string
+	Source      string
+	Tags        []string
+	// 单词本的目标语言，默认为 en
+	Language string
+	Created  time.Time
+	Updated  time.Time
+	// 从内置单词本安装时为内置单词本的名称和版本
+	Builtin  string
+	Revision int
+}
+
+type WordDetail struct {
+	Note string
+	// 导入时附带的自定义翻译和例句，练习时会和词典的内容一起显示
+	Translates []Translate
+	Sentences  []Sentence
+}
+
+// Record 为从其他格式读取的一个单词
+type Record struct {
+	Text string
+	WordDetail
+}
+
 type WordSet struct {
 	Name       string
 	Words      map[string]int
 	StorageDir string
+	Meta       WordSetMeta
+	// 单词的附加信息，没有附加信息的单词不在其中
+	Details map[string]*WordDetail
 }
 
 func NewWordSet(name, dir string) (WordSet, error) {
@@ -27,6 +60,7 @@ func NewWordSet(name, dir string) (WordSet, error) {
 		Name:       name,
 		StorageDir: dir,
 		Words:      map[string]int{},
+		Details:    map[string]*WordDetail{},
 	}, err
 }
 
@@ -45,7 +79,7 @@ func (ws WordSet) Exist() (bool, error) {
 	return true, err
 }
 
-func (ws WordSet) Save(force bool) error {
+func (ws *WordSet) Save(force bool) error {
 	file := ws.fileName()
 	exist, err := ws.Exist()
 	if exist && !force {
@@ -55,31 +89,151 @@ func (ws WordSet) Save(force bool) error {
 		return fmt.Errorf("WordSet Exist %s", err.Error())
 	}
 
-	f, err := os.Create(file)
+	now := time.Now()
+	if ws.Meta.Created.IsZero() {
+		ws.Meta.Created = now
+	}
+	ws.Meta.Updated = now
+	filebyte, err := encodeWordSet(ws)
 	if err != nil {
-		return err
+		return fmt.Errorf("WordSet encode %s %s", file, err.Error())
 	}
-	defer f.Close()
+	return ioutil.WriteFile(file, filebyte, 0644)
+}
 
-	w := bufio.NewWriter(f)
+// SortedWords 按字母顺序返回所有单词
+func (ws WordSet) SortedWords() []string {
+	words := make([]string, 0, len(ws.Words))
 	for word := range ws.Words {
-		word = strings.TrimSpace(word)
-		word = strings.ToLower(word)
-		_, err := w.WriteString(word + "\n")
-		if err != nil {
-			return err
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
+// Put 添加单词及其附加信息，已有的附加信息会被合并，word 无效时返回 false
+func (ws *WordSet) Put(word string, detail WordDetail) bool {
+	word = NormalizeWord(word)
+	if !validword.MatchString(word) {
+		return false
+	}
+	ws.Words[word] = 0
+	if detail.Note == "" && len(detail.Translates) == 0 && len(detail.Sentences) == 0 {
+		return true
+	}
+
+	d, ok := ws.Details[word]
+	if !ok {
+		d = &WordDetail{}
+		ws.Details[word] = d
+	}
+	if d.Note == "" {
+		d.Note = detail.Note
+	}
+	for _, t := range detail.Translates {
+		if !containsTranslate(d.Translates, t) {
+			d.Translates = append(d.Translates, t)
 		}
 	}
-	return w.Flush()
+	for _, sen := range detail.Sentences {
+		if !containsSentence(d.Sentences, sen) {
+			d.Sentences = append(d.Sentences, sen)
+		}
+	}
+	return true
+}
+
+// MergeDetail 把单词本中的自定义翻译和例句加到词典的查询结果前面
+func (ws WordSet) MergeDetail(word Word) Word {
+	d, ok := ws.Details[NormalizeWord(word.Text)]
+	if !ok {
+		return word
+	}
+	translates := append([]Translate{}, d.Translates...)
+	for _, t := range word.Translates {
+		if !containsTranslate(translates, t) {
+			translates = append(translates, t)
+		}
+	}
+	sentences := append([]Sentence{}, d.Sentences...)
+	for _, sen := range word.Sentences {
+		if !containsSentence(sentences, sen) {
+			sentences = append(sentences, sen)
+		}
+	}
+	word.Translates = translates
+	word.Sentences = sentences
+	return word
+}
+
+func containsTranslate(list []Translate, t Translate) bool {
+	for _, item := range list {
+		if item == t {
+			return true
+		}
+	}
+	return false
+}
+
+func containsSentence(list []Sentence, s Sentence) bool {
+	for _, item := range list {
+		if item.Text == s.Text {
+			return true
+		}
+	}
+	return false
+}
+
+func (ws WordSet) Note(word string) string {
+	if d, ok := ws.Details[word]; ok {
+		return d.Note
+	}
+	return ""
 }
 
 func (ws *WordSet) Append(word string) error {
-	word = strings.TrimSpace(word)
+	word = NormalizeWord(word)
 	ws.Words[word] = 0
 	return ws.Save(true)
 }
 
-var validword = regexp.MustCompile(`^[A-Za-z]+[A-Za-z]$`)
+// 单词或词组，允许单个字母、连字符、撇号和缩写的点，例如 a, well-known, o'clock, e.g., take off
+var validword = regexp.MustCompile(`^[A-Za-z]+(?:['.-][A-Za-z]+)*\.?(?: [A-Za-z]+(?:['.-][A-Za-z]+)*\.?)*$`)
+
+// NormalizeWord 统一单词的大小写、空白和撇号
+func NormalizeWord(word string) string {
+	word = strings.Replace(word, "’", "'", -1)
+	return NormalizeText(word)
+}
+
+func ValidWord(word string) bool {
+	return validword.MatchString(NormalizeWord(word))
+}
+
+type InvalidLine struct {
+	Line int
+	Text string
+}
+
+// 解析每行一个单词的内容，返回有效的单词和无效的行
+func parseWords(content string) ([]string, []InvalidLine) {
+	var (
+		words   []string
+		invalid []InvalidLine
+	)
+	for i, wordline := range strings.Split(content, "\n") {
+		word := NormalizeWord(wordline)
+		if word == "" {
+			continue
+		}
+		if !validword.MatchString(word) {
+			invalid = append(invalid, InvalidLine{Line: i + 1, Text: strings.TrimSpace(wordline)})
+			continue
+		}
+		words = append(words, word)
+	}
+	return words, invalid
+}
 
 func (ws *WordSet) Load() error {
 	exist, err := ws.Exist()
@@ -93,62 +247,203 @@ func (ws *WordSet) Load() error {
 		return fmt.Errorf("read file %s %s", file, err.Error())
 	}
 
-	for _, wordline := range strings.Split(string(filebyte), "\n") {
-		word := strings.TrimSpace(wordline)
-		if wordline == "" {
-			continue
-		}
-		if !validword.MatchString(word) {
-			return fmt.Errorf("WordSet Load invalid word %s", word)
-		}
-		ws.Words[word] = 0
+	err = decodeWordSet(ws, filebyte)
+	if err != nil {
+		return fmt.Errorf("WordSet Load %s %s", file, err.Error())
 	}
 	return nil
 }
 
+// 查询单词时自动加入的单词本，不能被重命名，删除时需要确认
+const DefaultWordSet = "default"
+
+var validname = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+func ValidName(name string) bool {
+	return validname.MatchString(name)
+}
+
 type WordSetManage struct {
 	StoragePath string
+	// 用于统计已记住的单词，为空时不统计
+	RestudyInterval map[int]int
 }
 
 func (m WordSetManage) WordSetDir() string {
 	return fmt.Sprintf("%s/wordset", m.StoragePath)
 }
 
-func (m WordSetManage) Import(p string) error {
-	name := strings.Split(path.Base(p), ".")[0]
-	if name == "/" {
-		return fmt.Errorf("invalid word set path %s ", name)
+// Load 加载已存在的单词本
+func (m WordSetManage) Load(name string) (WordSet, error) {
+	if !ValidName(name) {
+		return WordSet{}, fmt.Errorf("invalid word set name '%s'", name)
+	}
+	ws, err := NewWordSet(name, m.WordSetDir())
+	if err != nil {
+		return ws, err
 	}
+	exist, err := ws.Exist()
+	if err != nil {
+		return ws, err
+	}
+	if !exist {
+		return ws, fmt.Errorf("WrodSet %s not exist", name)
+	}
+	return ws, ws.Load()
+}
 
+// create 创建新的单词本，已存在时返回错误
+func (m WordSetManage) create(name string) (WordSet, error) {
+	if !ValidName(name) {
+		return WordSet{}, fmt.Errorf("invalid word set name '%s'", name)
+	}
 	ws, err := NewWordSet(name, m.WordSetDir())
 	if err != nil {
-		return err
+		return ws, err
+	}
+	exist, err := ws.Exist()
+	if err != nil {
+		return ws, err
+	}
+	if exist {
+		return ws, fmt.Errorf("WordSet %s already exist", name)
+	}
+	return ws, nil
+}
+
+// loadOrCreate 加载单词本，不存在时创建
+func (m WordSetManage) loadOrCreate(name string) (WordSet, error) {
+	if !ValidName(name) {
+		return WordSet{}, fmt.Errorf("invalid word set name '%s'", name)
+	}
+	ws, err := NewWordSet(name, m.WordSetDir())
+	if err != nil {
+		return ws, err
+	}
+	return ws, ws.Load()
+}
+
+// Due 返回需要复习的单词，name 不为空时只返回这个单词本中的单词
+func (m WordSetManage) Due(name string) ([]string, error) {
+	pe, err := m.extent()
+	if err != nil || pe == nil {
+		return nil, err
+	}
+	words := pe.ReviewWords()
+	if name != "" {
+		ws, err := m.Load(name)
+		if err != nil {
+			return nil, err
+		}
+		var inset []string
+		for _, w := range words {
+			if _, ok := ws.Words[w]; ok {
+				inset = append(inset, w)
+			}
+		}
+		words = inset
+	}
+	sort.Strings(words)
+	return words, nil
+}
+
+func (m WordSetManage) extent() (*PracExtent, error) {
+	if m.RestudyInterval == nil {
+		return nil, nil
 	}
-	err = ws.Load()
+	pe, err := NewPracExtent(PracExtentFile(m.StoragePath), m.RestudyInterval)
+	if err != nil {
+		return nil, err
+	}
+	return &pe, nil
+}
+
+// Import 导入每行一个单词的文件，name 为空时使用文件名作为单词本名称
+func (m WordSetManage) Import(p, name string) error {
+	if name == "" {
+		name = strings.Split(path.Base(p), ".")[0]
+	}
+	if name == "/" {
+		return fmt.Errorf("invalid word set path %s ", name)
+	}
+
+	ws, err := m.loadOrCreate(name)
 	if err != nil {
 		return err
 	}
+	if ws.Meta.Source == "" {
+		ws.Meta.Source = p
+	}
 
 	filebyte, err := ioutil.ReadFile(p)
 	if err != nil {
 		return fmt.Errorf("read file %s %s", p, err.Error())
 	}
 
-	for _, wordline := range strings.Split(string(filebyte), "\n") {
-		word := strings.TrimSpace(wordline)
-		if wordline == "" {
+	words, invalid := parseWords(string(filebyte))
+	for _, word := range words {
+		ws.Words[word] = 0
+	}
+	for _, l := range invalid {
+		fmt.Printf("%s:%d: invalid word '%s'\n", p, l.Line, l.Text)
+	}
+	fmt.Printf("imported %d words into %s, %d invalid lines\n", len(words), name, len(invalid))
+
+	return ws.Save(true)
+}
+
+// ImportRecords 把其他格式读取的单词导入单词本，source 记录为单词本的来源
+func (m WordSetManage) ImportRecords(name, source string, records []Record, invalid []InvalidLine) error {
+	ws, err := m.loadOrCreate(name)
+	if err != nil {
+		return err
+	}
+	if ws.Meta.Source == "" {
+		ws.Meta.Source = source
+	}
+
+	imported := 0
+	for _, r := range records {
+		if !ws.Put(r.Text, r.WordDetail) {
+			invalid = append(invalid, InvalidLine{Text: r.Text})
 			continue
 		}
-		if !validword.MatchString(word) {
-			return fmt.Errorf("WordSet Load invalid word '%s'", word)
+		imported++
+	}
+	for _, l := range invalid {
+		if l.Line == 0 {
+			fmt.Printf("%s: invalid word '%s'\n", source, l.Text)
+			continue
 		}
-		ws.Words[word] = 0
+		fmt.Printf("%s:%d: invalid word '%s'\n", source, l.Line, l.Text)
 	}
-
+	fmt.Printf("imported %d words into %s, %d invalid\n", imported, name, len(invalid))
 	return ws.Save(true)
 }
 
-func (m WordSetManage) Del(name string) error {
+// Export 以每行一个单词的格式导出
+func (m WordSetManage) Export(name string, w io.Writer) error {
+	ws, err := m.Load(name)
+	if err != nil {
+		return err
+	}
+	for _, word := range ws.SortedWords() {
+		_, err = fmt.Fprintln(w, word)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Del 删除单词本，默认单词本需要 force
+func (m WordSetManage) Del(name string, force bool) error {
+	if name == DefaultWordSet && !force {
+		return fmt.Errorf("WordSet %s is protected, use --force to delete it", name)
+	}
+	if !ValidName(name) {
+		return fmt.Errorf("invalid word set name '%s'", name)
+	}
 	ws, err := NewWordSet(name, m.WordSetDir())
 	if err != nil {
 		return err
@@ -164,36 +459,292 @@ func (m WordSetManage) Del(name string) error {
 	return os.Remove(ws.fileName())
 }
 
-func (m WordSetManage) List() error {
-	files, err := ioutil.ReadDir(m.WordSetDir())
+// Add 向单词本添加单词，单词本不存在时创建
+func (m WordSetManage) Add(name string, words []string) error {
+	added, invalid, err := m.AddWords(name, words)
+	for _, word := range invalid {
+		fmt.Printf("invalid word '%s'\n", word)
+	}
 	if err != nil {
 		return err
 	}
-	for _, f := range files {
-		fmt.Println(strings.Split(path.Base(f.Name()), ".")[0])
+	fmt.Printf("added %d words into %s\n", added, name)
+	return nil
+}
+
+// AddWords 把单词加入单词本，单词本不存在时创建，返回新加入的数量和无效的单词
+func (m WordSetManage) AddWords(name string, words []string) (added int, invalid []string, err error) {
+	ws, err := m.loadOrCreate(name)
+	if err != nil {
+		return 0, nil, err
 	}
+	for _, word := range words {
+		word = NormalizeWord(word)
+		if !validword.MatchString(word) {
+			invalid = append(invalid, word)
+			continue
+		}
+		if _, ok := ws.Words[word]; !ok {
+			added++
+		}
+		ws.Words[word] = 0
+	}
+	return added, invalid, ws.Save(true)
+}
+
+// Remove 从单词本中删除单词
+func (m WordSetManage) Remove(name string, words []string) error {
+	removed, missing, err := m.RemoveWords(name, words)
+	if err != nil {
+		return err
+	}
+	for _, word := range missing {
+		fmt.Printf("'%s' not in %s\n", word, name)
+	}
+	fmt.Printf("removed %d words from %s\n", removed, name)
 	return nil
 }
 
-func (m WordSetManage) Show(name string) error {
-	ws, err := NewWordSet(name, m.WordSetDir())
+// RemoveWords 从单词本中删除单词，返回删除的数量和不在单词本中的单词
+func (m WordSetManage) RemoveWords(name string, words []string) (removed int, missing []string, err error) {
+	ws, err := m.Load(name)
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, word := range words {
+		word = NormalizeWord(word)
+		if _, ok := ws.Words[word]; !ok {
+			missing = append(missing, word)
+			continue
+		}
+		delete(ws.Words, word)
+		delete(ws.Details, word)
+		removed++
+	}
+	return removed, missing, ws.Save(true)
+}
+
+func (m WordSetManage) Rename(old, new string) error {
+	if old == DefaultWordSet {
+		return fmt.Errorf("WordSet %s is protected and can not be renamed", old)
+	}
+	ws, err := m.Load(old)
+	if err != nil {
+		return err
+	}
+	dst, err := m.create(new)
 	if err != nil {
 		return err
 	}
+	return os.Rename(ws.fileName(), dst.fileName())
+}
 
-	exist, err := ws.Exist()
+// Copy 复制单词本，包括元数据和单词备注
+func (m WordSetManage) Copy(src, dst string) error {
+	ws, err := m.Load(src)
 	if err != nil {
 		return err
 	}
-	if !exist {
-		return fmt.Errorf("WrodSet %s not exist", name)
+	cp, err := m.create(dst)
+	if err != nil {
+		return err
 	}
-	err = ws.Load()
+	ws.Name = cp.Name
+	ws.Meta.Created = time.Time{}
+	return ws.Save(false)
+}
+
+// Merge 把 srcs 中的单词合并到 dst，dst 不存在时创建
+func (m WordSetManage) Merge(dst string, srcs []string) error {
+	if len(srcs) == 0 {
+		return errors.New("no word set to merge")
+	}
+	ws, err := m.loadOrCreate(dst)
 	if err != nil {
 		return err
 	}
-	for word := range ws.Words {
-		fmt.Println(word)
+	sets, err := m.loadAll(srcs)
+	if err != nil {
+		return err
+	}
+	before, total := len(ws.Words), len(ws.Words)
+	for _, other := range sets {
+		total += len(other.Words)
+	}
+	merged := ws.Union(sets...)
+	ws.Words, ws.Details = merged.Words, merged.Details
+	if ws.Meta.Source == "" {
+		ws.Meta.Source = "merge " + strings.Join(srcs, " ")
+	}
+	fmt.Printf("merged %d new words into %s, %d duplicates\n", len(ws.Words)-before, dst, total-len(ws.Words))
+	return ws.Save(true)
+}
+
+// Diff 列出只在 a 中（-）和只在 b 中（+）的单词
+func (m WordSetManage) Diff(a, b string) error {
+	wa, err := m.Load(a)
+	if err != nil {
+		return err
+	}
+	wb, err := m.Load(b)
+	if err != nil {
+		return err
+	}
+	for _, word := range wa.SortedWords() {
+		if _, ok := wb.Words[word]; !ok {
+			fmt.Printf("- %s\n", word)
+		}
+	}
+	for _, word := range wb.SortedWords() {
+		if _, ok := wa.Words[word]; !ok {
+			fmt.Printf("+ %s\n", word)
+		}
 	}
 	return nil
 }
+
+// Names 按字母顺序返回所有单词本的名称
+func (m WordSetManage) Names() ([]string, error) {
+	files, err := ioutil.ReadDir(m.WordSetDir())
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, f := range files {
+		if path.Ext(f.Name()) == ".wordset" {
+			names = append(names, strings.TrimSuffix(f.Name(), ".wordset"))
+		}
+	}
+	return names, nil
+}
+
+func (m WordSetManage) List() error {
+	names, err := m.Names()
+	if err != nil {
+		return err
+	}
+
+	pe, err := m.extent()
+	if err != nil {
+		return err
+	}
+	learned := func(ws WordSet) string {
+		if pe == nil || len(ws.Words) == 0 {
+			return "-"
+		}
+		n := 0
+		for word := range ws.Words {
+			if pe.Remembered(word) {
+				n++
+			}
+		}
+		return fmt.Sprintf("%.1f%%", float64(n)*100/float64(len(ws.Words)))
+	}
+
+	builtins := map[string]WordSet{}
+	for _, name := range BuiltinNames() {
+		builtin, err := LoadBuiltin(name)
+		if err != nil {
+			return err
+		}
+		builtins[name] = builtin
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tWORDS\tLEARNED\tLANGUAGE\tUPDATED\tDESCRIPTION")
+	installed := map[string]bool{}
+	for _, name := range names {
+		ws, err := m.Load(name)
+		if err != nil {
+			return err
+		}
+
+		// 从内置单词本安装的显示版本，有新版本时提示更新
+		status := "local"
+		if builtin, ok := builtins[ws.Meta.Builtin]; ok {
+			installed[ws.Meta.Builtin] = true
+			status = fmt.Sprintf("installed r%d", ws.Meta.Revision)
+			if builtin.Meta.Revision > ws.Meta.Revision {
+				status = fmt.Sprintf("update r%d", builtin.Meta.Revision)
+			}
+		}
+		updated := "-"
+		if !ws.Meta.Updated.IsZero() {
+			updated = ws.Meta.Updated.Format("2006-01-02")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n", ws.Name, status, len(ws.Words), learned(ws), ws.Meta.Language, updated, ws.Meta.Description)
+	}
+	for _, name := range BuiltinNames() {
+		if installed[name] {
+			continue
+		}
+		builtin := builtins[name]
+		fmt.Fprintf(w, "%s\tavailable r%d\t%d\t%s\t%s\t-\t%s\n", name, builtin.Meta.Revision, len(builtin.Words), learned(builtin), builtin.Meta.Language, builtin.Meta.Description)
+	}
+	return w.Flush()
+}
+
+// Show 列出单词本中的单词及练习状态
+// sortBy 为 alpha, correct 或 last，state 不为空时只列出该状态的单词
+func (m WordSetManage) Show(name, sortBy, state string) error {
+	ws, err := m.Load(name)
+	if err != nil {
+		return err
+	}
+	pe, err := m.extent()
+	if err != nil {
+		return err
+	}
+	if pe == nil {
+		if sortBy != "" && sortBy != "alpha" || state != "" {
+			return errors.New("RestudyInterval is required to sort or filter by practice state")
+		}
+		for _, word := range ws.SortedWords() {
+			fmt.Println(word)
+		}
+		return nil
+	}
+	if state != "" && !contains(States, state) {
+		return fmt.Errorf("invalid state %s, must be one of %s", state, strings.Join(States, ", "))
+	}
+
+	words := []string{}
+	for _, word := range ws.SortedWords() {
+		if state == "" || pe.State(word) == state {
+			words = append(words, word)
+		}
+	}
+	switch sortBy {
+	case "", "alpha":
+	case "correct":
+		sort.SliceStable(words, func(i, j int) bool {
+			return pe.CorrectNum(words[i]) > pe.CorrectNum(words[j])
+		})
+	case "last":
+		sort.SliceStable(words, func(i, j int) bool {
+			return pe.Last(words[i]) > pe.Last(words[j])
+		})
+	default:
+		return fmt.Errorf("invalid sort %s, must be one of alpha, correct, last", sortBy)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "WORD\tSTATE\tCORRECT\tLAST\tNOTE")
+	for _, word := range words {
+		last := "-"
+		if t := pe.Last(word); t != 0 {
+			last = time.Unix(t, 0).Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", word, pe.State(word), pe.CorrectNum(word), last, ws.Note(word))
+	}
+	return w.Flush()
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
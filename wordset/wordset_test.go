@@ -2,6 +2,9 @@ package wordset
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
 	"testing"
 )
 
@@ -12,3 +15,117 @@ func TestValidword(t *testing.T) {
 	fmt.Println(validword.MatchString("ab c"))
 	fmt.Println(validword.MatchString("ab嘿"))
 }
+
+func TestValidPhrase(t *testing.T) {
+	for word, valid := range map[string]bool{
+		"a":               true,
+		"I":               true,
+		"take off":        true,
+		"Take  Off":       true,
+		"well-known":      true,
+		"o'clock":         true,
+		"o’clock":         true,
+		"e.g.":            true,
+		"U.S.":            true,
+		"look forward to": true,
+		"1abc":            false,
+		"abc1":            false,
+		"ab嘿":             false,
+		"-abc":            false,
+		"abc-":            false,
+		"a--b":            false,
+		"":                false,
+	} {
+		if ValidWord(word) != valid {
+			t.Errorf("ValidWord(%q) = %v", word, !valid)
+		}
+	}
+}
+
+func TestParseWords(t *testing.T) {
+	words, invalid := parseWords("apple\n\n take  off \nbad1\nwell-known\n")
+	if len(words) != 3 || words[1] != "take off" {
+		t.Errorf("words %v", words)
+	}
+	if len(invalid) != 1 || invalid[0].Line != 4 || invalid[0].Text != "bad1" {
+		t.Errorf("invalid %v", invalid)
+	}
+}
+
+func TestWordSetManage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	m := WordSetManage{StoragePath: dir}
+
+	err = m.Add(DefaultWordSet, []string{"apple", "take off", "bad1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.Add("work", []string{"apple", "idempotent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Del(DefaultWordSet, false) == nil {
+		t.Errorf("default word set deleted without force")
+	}
+	if m.Rename(DefaultWordSet, "other") == nil {
+		t.Errorf("default word set renamed")
+	}
+	if m.Copy("work", DefaultWordSet) == nil {
+		t.Errorf("copy overwrote existing word set")
+	}
+	if m.Add("../escape", []string{"apple"}) == nil {
+		t.Errorf("invalid word set name accepted")
+	}
+	// 单词本目录外的文件
+	err = ioutil.WriteFile(path.Join(dir, "outside.wordset"), nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Load("../outside"); err == nil {
+		t.Errorf("loaded word set outside the word set dir")
+	}
+	if _, _, err := m.RemoveWords("../outside", []string{"apple"}); err == nil {
+		t.Errorf("removed words outside the word set dir")
+	}
+	if m.Del("../outside", true) == nil {
+		t.Errorf("deleted word set outside the word set dir")
+	}
+	if _, err := os.Stat(path.Join(dir, "outside.wordset")); err != nil {
+		t.Errorf("outside file %v", err)
+	}
+
+	err = m.Merge("all", []string{DefaultWordSet, "work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := m.Load("all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all.Words) != 3 {
+		t.Errorf("merged %v", all.Words)
+	}
+
+	err = m.Rename("work", "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.Remove("job", []string{"apple"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := m.Load("job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(job.Words) != 1 {
+		t.Errorf("job %v", job.Words)
+	}
+	if _, err := m.Load("work"); err == nil {
+		t.Errorf("renamed word set still exists")
+	}
+}